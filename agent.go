@@ -7,11 +7,10 @@ import (
 	"github.com/telemetryapp/gotelemetry_agent/agent/config"
 	"github.com/telemetryapp/gotelemetry_agent/agent/functions"
 	"github.com/telemetryapp/gotelemetry_agent/agent/job"
+	"github.com/telemetryapp/gotelemetry_agent/plugin"
 	"io/ioutil"
 	"log"
 	"os"
-
-	_ "github.com/telemetryapp/gotelemetry_agent/plugin"
 )
 
 var configFile *config.ConfigFile
@@ -20,10 +19,30 @@ var completionChannel chan bool
 
 func main() {
 	if config.CLIConfig.WantsFunctionHelp {
+		// Include user-defined functions if a configuration file is available
+		if cfg, err := config.NewConfigFile(); err == nil {
+			if err := functions.RegisterUserFunctions(cfg); err != nil {
+				log.Fatalf("Initialization error: %s", err)
+			}
+		}
+
+		if config.CLIConfig.FunctionHelpFormat != "text" {
+			if err := functions.ExportReference(config.CLIConfig.FunctionHelpFormat, config.CLIConfig.FunctionHelpName, os.Stdout); err != nil {
+				log.Fatalf("Error: %s", err)
+			}
+
+			return
+		}
+
 		functions.PrintHelp(config.CLIConfig.FunctionHelpName)
 		return
 	}
 
+	if config.CLIConfig.WantsDriverList {
+		plugin.PrintSQLDrivers()
+		return
+	}
+
 	var err error
 
 	configFile, err = config.NewConfigFile()
@@ -32,11 +51,23 @@ func main() {
 		log.Fatalf("Initialization error: %s", err)
 	}
 
+	if err := functions.RegisterUserFunctions(configFile); err != nil {
+		log.Fatalf("Initialization error: %s", err)
+	}
+
+	for _, err := range functions.GetFunctionRegistrationErrors() {
+		log.Printf("Warning: %s", err)
+	}
+
 	errorChannel = make(chan error, 0)
 	completionChannel = make(chan bool, 0)
 
 	go run()
 
+	// Evaluating an expression is a one-off command, so its errors are reported with
+	// a non-zero exit status that scripts can check.
+	failed := false
+
 	for {
 		select {
 		case err := <-errorChannel:
@@ -62,6 +93,10 @@ func main() {
 
 			log.Printf("Error: %s", err.Error())
 
+			if config.CLIConfig.IsEvaluating {
+				failed = true
+			}
+
 		case <-completionChannel:
 			goto Done
 		}
@@ -70,6 +105,10 @@ func main() {
 Done:
 
 	log.Println("No more jobs to run; exiting.\n")
+
+	if failed {
+		os.Exit(1)
+	}
 }
 
 func run() {
@@ -87,9 +126,37 @@ func run() {
 		}
 
 		agent.ProcessPipeRequest(configFile, errorChannel, completionChannel, payload)
+	} else if config.CLIConfig.IsEvaluating {
+		if config.CLIConfig.EvalInteractive {
+			agent.ProcessEvalSession(errorChannel, completionChannel, config.CLIConfig.EvalDryRun)
+			return
+		}
+
+		var source []byte
+
+		switch {
+		case config.CLIConfig.EvalExpression != "":
+			source = []byte(config.CLIConfig.EvalExpression)
+
+		case config.CLIConfig.EvalFile != "" && config.CLIConfig.EvalFile != "-":
+			source, err = ioutil.ReadFile(config.CLIConfig.EvalFile)
+
+		default:
+			source, err = ioutil.ReadAll(os.Stdin)
+		}
+
+		if err != nil {
+			errorChannel <- err
+			completionChannel <- true
+			return
+		}
+
+		agent.ProcessEvalRequest(errorChannel, completionChannel, source, config.CLIConfig.EvalDryRun)
 	} else if config.CLIConfig.IsNotifying {
 		agent.ProcessNotificationRequest(configFile, errorChannel, completionChannel, config.CLIConfig.NotificationChannel, config.CLIConfig.Notification)
 	} else {
+		aggregations.StartRollups()
+
 		_, err := job.NewJobManager(configFile, errorChannel, completionChannel)
 
 		if err != nil {
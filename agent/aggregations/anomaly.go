@@ -0,0 +1,230 @@
+package aggregations
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+type AnomalyMethod int
+
+const (
+	ZScore AnomalyMethod = iota
+	MedianAbsoluteDeviation
+)
+
+var anomalyMethodNames = map[string]AnomalyMethod{
+	"zscore": ZScore,
+	"mad":    MedianAbsoluteDeviation,
+}
+
+func GetAnomalyMethod(name string) (AnomalyMethod, error) {
+	if method, ok := anomalyMethodNames[name]; ok {
+		return method, nil
+	}
+
+	return ZScore, errors.New(fmt.Sprintf("Unknown anomaly detection method %s", name))
+}
+
+type AnomalyDirection int
+
+const (
+	BothDirections AnomalyDirection = iota
+	AboveBaseline
+	BelowBaseline
+)
+
+var anomalyDirectionNames = map[string]AnomalyDirection{
+	"both":  BothDirections,
+	"above": AboveBaseline,
+	"below": BelowBaseline,
+}
+
+func GetAnomalyDirection(name string) (AnomalyDirection, error) {
+	if direction, ok := anomalyDirectionNames[name]; ok {
+		return direction, nil
+	}
+
+	return BothDirections, errors.New(fmt.Sprintf("Unknown anomaly direction %s", name))
+}
+
+// The factor that makes the median absolute deviation of normally distributed values
+// equal to their standard deviation, so that MAD scores can be read like z-scores.
+const madScale = 1.4826
+
+// Struct AnomalyOptions describes how anomalies are detected.
+//
+// The series is aggregated in intervals of Interval seconds that end at the current
+// time, so that the most recent interval is always complete. The last Count intervals
+// are then each compared to a baseline made of the Baseline intervals that precede
+// them; if SeasonLength is greater than zero, only the intervals that are a multiple
+// of SeasonLength intervals earlier are part of the baseline, so that, for example,
+// each hour is compared to the same hour in the previous weeks.
+//
+// An interval is an anomaly if its score, the number of standard deviations (or scaled
+// median absolute deviations) that separate it from the baseline, exceeds Threshold in
+// the given Direction.
+type AnomalyOptions struct {
+	Method       AnomalyMethod
+	Direction    AnomalyDirection
+	Interval     int
+	Baseline     int
+	SeasonLength int
+	Count        int
+	Threshold    float64
+}
+
+// DetectAnomalies compares the most recent values of a series to their baseline. It
+// returns an array with one object for each interval, from the oldest to the most
+// recent, that contains its timestamp and value, the expected value and range, the
+// score, and a boolean flag that tells whether the value is an anomaly.
+//
+// The score is null if the interval has no values, if the baseline has fewer than two
+// values, or if the baseline doesn't vary at all and the value differs from it; in the
+// latter case, the value is still flagged as an anomaly.
+func (s *Series) DetectAnomalies(functionType FunctionType, options AnomalyOptions) ([]interface{}, error) {
+	if options.Interval <= 0 || options.Baseline <= 0 || options.Count <= 0 {
+		return nil, errors.New("The interval, baseline and count of an anomaly detection must be greater than zero")
+	}
+
+	if options.SeasonLength < 0 {
+		return nil, errors.New("The season length of an anomaly detection cannot be negative")
+	}
+
+	if options.SeasonLength > options.Baseline {
+		return nil, errors.New("The baseline of an anomaly detection must cover at least one season")
+	}
+
+	if options.Threshold <= 0 {
+		return nil, errors.New("The threshold of an anomaly detection must be greater than zero")
+	}
+
+	total := options.Count + options.Baseline
+
+	// Count the intervals back from the current time, so that the last one includes
+	// the values recorded up to and including now, like Compute does
+	start := int(time.Now().Unix()) - total*options.Interval + 1
+
+	rows, err := s.buckets(functionType, start, options.Interval)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return scoreAnomalies(rows, start, zeroWhenEmpty(functionType), options), nil
+}
+
+// scoreAnomalies compares the last Count of the intervals that begin at start to their
+// baseline, as described by DetectAnomalies. The rows map the offset of each interval
+// from start, in seconds, to its aggregated value; intervals without a row are zero if
+// zeroWhenEmpty is true, and have no value otherwise.
+func scoreAnomalies(rows map[int]float64, start int, zeroWhenEmpty bool, options AnomalyOptions) []interface{} {
+	total := options.Count + options.Baseline
+
+	value := func(index int) (float64, bool) {
+		result, ok := rows[index*options.Interval]
+
+		if !ok && zeroWhenEmpty {
+			return 0.0, true
+		}
+
+		return result, ok
+	}
+
+	step := 1
+
+	if options.SeasonLength > 0 {
+		step = options.SeasonLength
+	}
+
+	output := []interface{}{}
+
+	for index := options.Baseline; index < total; index++ {
+		baseline := []float64{}
+
+		for previous := index - step; previous >= index-options.Baseline; previous -= step {
+			if v, ok := value(previous); ok {
+				baseline = append(baseline, v)
+			}
+		}
+
+		result := map[string]interface{}{
+			"ts":       start + index*options.Interval,
+			"value":    nil,
+			"expected": nil,
+			"lower":    nil,
+			"upper":    nil,
+			"score":    nil,
+			"anomaly":  false,
+		}
+
+		v, ok := value(index)
+
+		if ok {
+			result["value"] = v
+		}
+
+		// The spread of a baseline can't be estimated from a single value
+		if len(baseline) < 2 {
+			output = append(output, result)
+			continue
+		}
+
+		var center, deviation float64
+
+		switch options.Method {
+		case MedianAbsoluteDeviation:
+			center = percentile(baseline, 50)
+
+			deviations := make([]float64, len(baseline))
+
+			for i, v := range baseline {
+				deviations[i] = math.Abs(v - center)
+			}
+
+			deviation = madScale * percentile(deviations, 50)
+
+		default:
+			center = sum(baseline) / float64(len(baseline))
+			deviation = math.Sqrt(variance(baseline))
+		}
+
+		result["expected"] = center
+		result["lower"] = center - options.Threshold*deviation
+		result["upper"] = center + options.Threshold*deviation
+
+		if !ok {
+			output = append(output, result)
+			continue
+		}
+
+		var score float64
+
+		if deviation > 0 {
+			score = (v - center) / deviation
+			result["score"] = score
+		} else if v > center {
+			score = math.Inf(1)
+		} else if v < center {
+			score = math.Inf(-1)
+		} else {
+			result["score"] = 0.0
+		}
+
+		switch options.Direction {
+		case AboveBaseline:
+			result["anomaly"] = score > options.Threshold
+
+		case BelowBaseline:
+			result["anomaly"] = score < -options.Threshold
+
+		default:
+			result["anomaly"] = math.Abs(score) > options.Threshold
+		}
+
+		output = append(output, result)
+	}
+
+	return output
+}
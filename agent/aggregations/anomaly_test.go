@@ -0,0 +1,152 @@
+package aggregations
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScoreAnomalies(t *testing.T) {
+	nan := math.NaN()
+
+	tests := []struct {
+		name          string
+		values        []float64
+		zeroWhenEmpty bool
+		options       AnomalyOptions
+		expected      []map[string]interface{}
+	}{
+		{
+			name:    "z-score",
+			values:  []float64{10, 12, 10, 12, 11, 30},
+			options: AnomalyOptions{Baseline: 4, Count: 2, Threshold: 2},
+			expected: []map[string]interface{}{
+				{"value": 11.0, "expected": 11.0, "lower": 9.0, "upper": 13.0, "score": 0.0, "anomaly": false},
+				{"value": 30.0, "expected": 11.25, "score": 18.75 / math.Sqrt(0.6875), "anomaly": true},
+			},
+		},
+		{
+			name:    "below the baseline only",
+			values:  []float64{10, 12, 10, 12, 0},
+			options: AnomalyOptions{Direction: BelowBaseline, Baseline: 4, Count: 1, Threshold: 2},
+			expected: []map[string]interface{}{
+				{"value": 0.0, "score": -11.0, "anomaly": true},
+			},
+		},
+		{
+			name:    "above the baseline, when only values below it are anomalies",
+			values:  []float64{10, 12, 10, 12, 30},
+			options: AnomalyOptions{Direction: BelowBaseline, Baseline: 4, Count: 1, Threshold: 2},
+			expected: []map[string]interface{}{
+				{"value": 30.0, "score": 19.0, "anomaly": false},
+			},
+		},
+		{
+			name:    "above the baseline only",
+			values:  []float64{10, 12, 10, 12, 0},
+			options: AnomalyOptions{Direction: AboveBaseline, Baseline: 4, Count: 1, Threshold: 2},
+			expected: []map[string]interface{}{
+				{"value": 0.0, "score": -11.0, "anomaly": false},
+			},
+		},
+		{
+			name:    "median absolute deviation",
+			values:  []float64{10, 12, 10, 12, 14, 1000, 14},
+			options: AnomalyOptions{Method: MedianAbsoluteDeviation, Baseline: 4, Count: 3, Threshold: 2},
+			expected: []map[string]interface{}{
+				{"expected": 11.0, "lower": 11 - 2*madScale, "upper": 11 + 2*madScale, "score": 3 / madScale, "anomaly": true},
+				{"expected": 12.0, "score": 988 / madScale, "anomaly": true},
+				// The outlier in the baseline barely moves the median
+				{"expected": 13.0, "score": 1 / (2 * madScale), "anomaly": false},
+			},
+		},
+		{
+			// Without the season, the baseline would vary too much to flag the value
+			name:    "seasonal baseline",
+			values:  []float64{100, 1, 110, 2, 90, 3, 2},
+			options: AnomalyOptions{Baseline: 6, SeasonLength: 2, Count: 1, Threshold: 2},
+			expected: []map[string]interface{}{
+				{"value": 2.0, "expected": 100.0, "score": -98 / math.Sqrt(200.0/3), "anomaly": true},
+			},
+		},
+		{
+			name:    "seasonal baseline in phase",
+			values:  []float64{100, 1, 110, 2, 90, 3, 105, 2},
+			options: AnomalyOptions{Baseline: 6, SeasonLength: 2, Count: 2, Threshold: 2},
+			expected: []map[string]interface{}{
+				{"value": 105.0, "expected": 100.0, "score": 5 / math.Sqrt(200.0/3), "anomaly": false},
+				{"value": 2.0, "expected": 2.0, "score": 0.0, "anomaly": false},
+			},
+		},
+		{
+			name:    "interval without values",
+			values:  []float64{10, 12, 10, 12, nan},
+			options: AnomalyOptions{Baseline: 4, Count: 1, Threshold: 2},
+			expected: []map[string]interface{}{
+				{"value": nil, "expected": 11.0, "score": nil, "anomaly": false},
+			},
+		},
+		{
+			name:          "interval without values that counts as zero",
+			values:        []float64{10, 12, 10, 12, nan},
+			zeroWhenEmpty: true,
+			options:       AnomalyOptions{Baseline: 4, Count: 1, Threshold: 2},
+			expected: []map[string]interface{}{
+				{"value": 0.0, "score": -11.0, "anomaly": true},
+			},
+		},
+		{
+			name:    "baseline with a single value",
+			values:  []float64{nan, nan, nan, 12, 50},
+			options: AnomalyOptions{Baseline: 4, Count: 1, Threshold: 2},
+			expected: []map[string]interface{}{
+				{"value": 50.0, "expected": nil, "lower": nil, "score": nil, "anomaly": false},
+			},
+		},
+		{
+			name:    "flat baseline",
+			values:  []float64{5, 5, 5, 5, 5, 6},
+			options: AnomalyOptions{Baseline: 4, Count: 2, Threshold: 2},
+			expected: []map[string]interface{}{
+				{"value": 5.0, "expected": 5.0, "score": 0.0, "anomaly": false},
+				{"value": 6.0, "expected": 5.0, "score": nil, "anomaly": true},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test.options.Interval = 60
+
+		start := 1000
+		result := scoreAnomalies(forecastTestRows(test.values...), start, test.zeroWhenEmpty, test.options)
+
+		if len(result) != len(test.expected) {
+			t.Errorf("%s: expected %d intervals, got %d", test.name, len(test.expected), len(result))
+			continue
+		}
+
+		for index, expected := range test.expected {
+			actual := result[index].(map[string]interface{})
+
+			if ts := start + (test.options.Baseline+index)*60; actual["ts"] != ts {
+				t.Errorf("%s, interval %d: expected the timestamp %d, got %v", test.name, index, ts, actual["ts"])
+			}
+
+			for key, value := range expected {
+				if !anomalyValuesEqual(actual[key], value) {
+					t.Errorf("%s, interval %d: expected %s to be %v, got %v", test.name, index, key, value, actual[key])
+				}
+			}
+		}
+	}
+}
+
+func anomalyValuesEqual(a, b interface{}) bool {
+	x, ok := a.(float64)
+	y, ok2 := b.(float64)
+
+	if ok && ok2 {
+		return x == y || math.Abs(x-y) < 1e-9
+	}
+
+	return a == b
+}
@@ -2,17 +2,25 @@ package aggregations
 
 import (
 	"code.google.com/p/go-sqlite/go1/sqlite3"
+	"errors"
 	"fmt"
 	"github.com/telemetryapp/gotelemetry"
+	"sort"
 )
 
 type Context struct {
 	conn          *sqlite3.Conn
 	hasError      bool
 	inTransaction bool
+	createdSeries []string
+	scopes        []map[string]interface{}
 }
 
 func GetContext() (*Context, error) {
+	if manager == nil {
+		return nil, errors.New("The data layer is not available. Set the `data.path` property in the configuration file to enable it.")
+	}
+
 	conn, err := sqlite3.Open(manager.path)
 
 	if err != nil {
@@ -52,14 +60,111 @@ func (c *Context) Begin() error {
 	return c.conn.Begin()
 }
 
-func (c *Context) Close() {
-	if c.inTransaction {
-		if c.hasError {
-			c.conn.Rollback()
-		} else {
-			c.conn.Commit()
+// Commit ends the current transaction, if any. The transaction is committed, unless an
+// error was recorded with SetError, in which case it is rolled back. The result is nil
+// only if the changes made during the transaction have been saved.
+func (c *Context) Commit() error {
+	if !c.inTransaction {
+		return nil
+	}
+
+	c.inTransaction = false
+
+	if !c.hasError {
+		err := c.conn.Commit()
+
+		if err == nil {
+			return nil
 		}
+
+		c.rollback()
+
+		return err
 	}
 
+	c.rollback()
+
+	return errors.New("The transaction was rolled back because of an earlier error")
+}
+
+func (c *Context) rollback() {
+	c.conn.Rollback()
+
+	// The tables created during the transaction no longer exist
+	for _, name := range c.createdSeries {
+		delete(cachedSeries, name)
+		delete(cachedEventSeries, name)
+	}
+}
+
+// Close ends the current transaction, like Commit, and closes the connection.
+func (c *Context) Close() {
+	c.Commit()
+
 	c.conn.Close()
 }
+
+// Variables
+//
+// A context holds a stack of variable scopes. The outermost scope lasts as long as the
+// context itself, so that all the expressions evaluated during the same run (for example,
+// the command lines output by a process) can share values; the expression language
+// pushes additional scopes to bind variables that are only visible to a subexpression.
+
+// PushScope creates a new variable scope nested inside the current one.
+func (c *Context) PushScope() {
+	if len(c.scopes) == 0 {
+		c.scopes = append(c.scopes, map[string]interface{}{})
+	}
+
+	c.scopes = append(c.scopes, map[string]interface{}{})
+}
+
+// PopScope discards the innermost variable scope and all the variables it contains.
+// The outermost scope is never discarded.
+func (c *Context) PopScope() {
+	if len(c.scopes) > 1 {
+		c.scopes = c.scopes[:len(c.scopes)-1]
+	}
+}
+
+// SetVariable assigns a value to a variable in the innermost scope.
+func (c *Context) SetVariable(name string, value interface{}) {
+	if len(c.scopes) == 0 {
+		c.scopes = append(c.scopes, map[string]interface{}{})
+	}
+
+	c.scopes[len(c.scopes)-1][name] = value
+}
+
+// GetVariable returns the value of a variable, looking it up from the innermost scope
+// outwards.
+func (c *Context) GetVariable(name string) (interface{}, bool) {
+	for index := len(c.scopes) - 1; index >= 0; index-- {
+		if value, ok := c.scopes[index][name]; ok {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
+
+// VariableNames returns the sorted names of all the variables that are visible from
+// the innermost scope.
+func (c *Context) VariableNames() []string {
+	seen := map[string]bool{}
+	result := []string{}
+
+	for _, scope := range c.scopes {
+		for name := range scope {
+			if !seen[name] {
+				seen[name] = true
+				result = append(result, name)
+			}
+		}
+	}
+
+	sort.Strings(result)
+
+	return result
+}
@@ -0,0 +1,141 @@
+package aggregations
+
+import (
+	"io"
+	"time"
+)
+
+// Struct sample is a single data point of a series.
+type sample struct {
+	ts    int64
+	value float64
+}
+
+// samples runs a query that returns timestamps and values.
+func (s *Series) samples(query string, args ...interface{}) ([]sample, error) {
+	result := []sample{}
+
+	rs, err := s.query(query, args...)
+
+	if err == io.EOF {
+		return result, nil
+	}
+
+	for ; err == nil; err = rs.Next() {
+		var p sample
+
+		if err := rs.Scan(&p.ts, &p.value); err != nil {
+			rs.Close()
+			return nil, err
+		}
+
+		result = append(result, p)
+	}
+
+	if err != io.EOF {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// samplesSince returns the data points recorded from start onwards, sorted by timestamp,
+// preceded by the last data point recorded before start, if there is one. That point
+// is the baseline from which changes are measured.
+func (s *Series) samplesSince(start, end time.Time) ([]sample, error) {
+	if err := s.requireRawValues(start); err != nil {
+		return nil, err
+	}
+
+	baseline, err := s.samples("SELECT ts, value FROM ?? WHERE ts < ? ORDER BY ts DESC, rowid DESC LIMIT 1", start)
+
+	if err != nil {
+		return nil, err
+	}
+
+	samples, err := s.samples("SELECT ts, value FROM ?? WHERE ts BETWEEN ? AND ? ORDER BY ts, rowid", start, end)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return append(baseline, samples...), nil
+}
+
+// counterChange returns the change between two consecutive values. If the series is a
+// counter, values are only supposed to increase, and a decrease means that the counter
+// was reset; like Prometheus, we then assume that it restarted from zero, so the change
+// is the new value.
+func counterChange(previous, current float64, counter bool) float64 {
+	if counter && current < previous {
+		return current
+	}
+
+	return current - previous
+}
+
+// Change returns the difference between the most recent value recorded between start
+// and end and the last value recorded before start, along with the number of seconds
+// between the two. If the series has no value before start, the first value after it
+// is used instead.
+//
+// If counter is true, the series is treated as a counter, and the result is the
+// sum of the increases between consecutive values, taking counter resets into account.
+func (s *Series) Change(start, end time.Time, counter bool) (float64, float64, error) {
+	samples, err := s.samplesSince(start, end)
+
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+
+	if len(samples) < 2 {
+		return 0.0, 0.0, nil
+	}
+
+	result := 0.0
+
+	for index := 1; index < len(samples); index++ {
+		result += counterChange(samples[index-1].value, samples[index].value, counter)
+	}
+
+	return result, float64(samples[len(samples)-1].ts - samples[0].ts), nil
+}
+
+// Rate returns the average per-second rate at which a counter increased between
+// start and end, or zero if there isn't enough data to compute it.
+func (s *Series) Rate(start, end time.Time, counter bool) (float64, error) {
+	change, elapsed, err := s.Change(start, end, counter)
+
+	if err != nil || elapsed == 0 {
+		return 0.0, err
+	}
+
+	return change / elapsed, nil
+}
+
+// aggregateChanges computes the increase of a counter in each of the intervals that
+// begin at start, optionally converted into a per-second rate. Each change is assigned
+// to the interval in which the value that completes it was recorded.
+func (s *Series) aggregateChanges(start, interval int, perSecond bool) (map[int]float64, error) {
+	samples, err := s.samplesSince(time.Unix(int64(start), 0), time.Now())
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[int]float64{}
+
+	for index := 1; index < len(samples); index++ {
+		bucket := int(samples[index].ts-int64(start)) / interval * interval
+
+		result[bucket] += counterChange(samples[index-1].value, samples[index].value, true)
+	}
+
+	if perSecond {
+		for bucket, change := range result {
+			result[bucket] = change / float64(interval)
+		}
+	}
+
+	return result, nil
+}
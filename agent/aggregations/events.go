@@ -0,0 +1,219 @@
+package aggregations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Struct EventSeries is a series whose values are arbitrary JSON documents, such as the
+// details of a deploy or of a support ticket, rather than numbers. Event series share
+// their names with numeric series, so a name can only be used for one kind of series.
+//
+// Like the keys of the key/value store, each event can optionally expire after a
+// certain amount of time; expired events behave as if they had been deleted.
+type EventSeries struct {
+	table *Series
+	Name  string
+}
+
+var cachedEventSeries = map[string]bool{}
+
+func GetEventSeries(context *Context, name string) (*EventSeries, error) {
+	if err := validateSeriesName(name); err != nil {
+		return nil, err
+	}
+
+	result := &EventSeries{
+		table: &Series{
+			context: context,
+			Name:    name,
+		},
+		Name: name,
+	}
+
+	if !cachedEventSeries[name] {
+		if err := result.createTable(); err != nil {
+			return nil, err
+		}
+
+		cachedEventSeries[name] = true
+		context.createdSeries = append(context.createdSeries, name)
+	}
+
+	return result, nil
+}
+
+func (s *EventSeries) createTable() error {
+	if err := s.table.exec("CREATE TABLE IF NOT EXISTS ?? (ts INT NOT NULL, event TEXT NOT NULL, expires INT)"); err != nil {
+		return err
+	}
+
+	if err := s.table.exec("CREATE INDEX IF NOT EXISTS ??_index ON ?? (ts)"); err != nil {
+		return err
+	}
+
+	isEventSeries, err := s.table.hasColumn("event")
+
+	if err != nil {
+		return err
+	}
+
+	if !isEventSeries {
+		return errors.New(fmt.Sprintf("The series `%s` is a numeric series, and cannot be used with the event functions.", s.Name))
+	}
+
+	return nil
+}
+
+// purge removes the expired events from the series.
+func (s *EventSeries) purge() error {
+	return s.table.exec("DELETE FROM ?? WHERE expires IS NOT NULL AND expires <= ?", time.Now().Unix())
+}
+
+// Push appends an event to the series. If ttl is greater than zero, the event expires
+// after that amount of time; otherwise, it never expires.
+func (s *EventSeries) Push(timestamp *time.Time, event interface{}, ttl time.Duration) error {
+	if timestamp == nil {
+		timestamp = &time.Time{}
+		*timestamp = time.Now()
+	}
+
+	source, err := json.Marshal(event)
+
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to encode the event: %s", err))
+	}
+
+	if err := s.purge(); err != nil {
+		return err
+	}
+
+	return s.table.exec("INSERT INTO ?? (ts, event, expires) VALUES (?, ?, ?)", *timestamp, string(source), expiry(ttl))
+}
+
+// eachEvent calls a function with the timestamp and the decoded document of each event
+// recorded from start onwards that hasn't expired and matches a filter, from the most
+// recent to the oldest, until the function returns false.
+func (s *EventSeries) eachEvent(start time.Time, filter map[string]interface{}, f func(int64, interface{}) bool) error {
+	rs, err := s.table.query("SELECT ts, event FROM ?? WHERE ts >= ? AND (expires IS NULL OR expires > ?) ORDER BY ts DESC, rowid DESC", start, time.Now().Unix())
+
+	if err == io.EOF {
+		return nil
+	}
+
+	for ; err == nil; err = rs.Next() {
+		var ts int64
+		var source string
+
+		if err := rs.Scan(&ts, &source); err != nil {
+			rs.Close()
+			return err
+		}
+
+		var event interface{}
+
+		if err := json.Unmarshal([]byte(source), &event); err != nil {
+			rs.Close()
+			return errors.New(fmt.Sprintf("An event of the series `%s` is not valid JSON: %s", s.Name, err))
+		}
+
+		if !matchesFilter(event, filter) {
+			continue
+		}
+
+		if !f(ts, event) {
+			rs.Close()
+			return nil
+		}
+	}
+
+	if err != io.EOF {
+		return err
+	}
+
+	return nil
+}
+
+// matchesFilter returns true if an event has all the fields of a filter, with the same
+// values. Nested fields are named by joining the names of their parents with dots, like
+// `author.name`.
+func matchesFilter(event interface{}, filter map[string]interface{}) bool {
+	for path, expected := range filter {
+		value := event
+
+		for _, field := range strings.Split(path, ".") {
+			object, ok := value.(map[string]interface{})
+
+			if !ok {
+				return false
+			}
+
+			if value, ok = object[field]; !ok {
+				return false
+			}
+		}
+
+		if !reflect.DeepEqual(value, expected) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Latest returns up to count of the most recent events that match a filter, from the
+// most recent to the oldest, as an array of {ts, event} objects.
+func (s *EventSeries) Latest(count int, filter map[string]interface{}) ([]interface{}, error) {
+	result := []interface{}{}
+
+	if count <= 0 {
+		return result, nil
+	}
+
+	err := s.eachEvent(time.Unix(0, 0), filter, func(ts int64, event interface{}) bool {
+		result = append(result, map[string]interface{}{"ts": ts, "event": event})
+
+		return len(result) < count
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Count returns the number of events that match a filter in each of the last count
+// intervals of the given number of seconds, as an array of {ts, value} objects like
+// Series.Aggregate does.
+func (s *EventSeries) Count(interval, count int, filter map[string]interface{}) (interface{}, error) {
+	start := int(time.Now().Add(-time.Duration(interval*count)*time.Second).Unix()) / interval * interval
+
+	rows := map[int]int{}
+
+	err := s.eachEvent(time.Unix(int64(start), 0), filter, func(ts int64, event interface{}) bool {
+		rows[(int(ts)-start)/interval*interval]++
+
+		return true
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	output := []interface{}{}
+
+	for index := 0; index < count; index++ {
+		t := index * interval
+		ts := start + t
+
+		output = append(output, map[string]interface{}{"ts": ts, "value": rows[t]})
+	}
+
+	return interface{}(output), nil
+}
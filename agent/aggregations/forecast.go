@@ -0,0 +1,311 @@
+package aggregations
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+type ForecastModel int
+
+const (
+	LinearForecast ForecastModel = iota
+	HoltWintersForecast
+)
+
+var forecastModelNames = map[string]ForecastModel{
+	"linear":       LinearForecast,
+	"holt_winters": HoltWintersForecast,
+}
+
+func GetForecastModel(name string) (ForecastModel, error) {
+	if model, ok := forecastModelNames[name]; ok {
+		return model, nil
+	}
+
+	return LinearForecast, errors.New(fmt.Sprintf("Unknown forecast model %s", name))
+}
+
+// Struct ForecastOptions describes how a forecast is computed.
+//
+// The series is first aggregated in intervals of Interval seconds, exactly like
+// Aggregate does, over the last History complete intervals; the model is fitted to
+// those values, and then projected over the next Horizon intervals, starting with the
+// current one, which is still incomplete.
+//
+// SeasonLength is the number of intervals after which the series repeats itself (for
+// example, 24 for a daily pattern in hourly intervals), and is only used by the
+// Holt-Winters model; zero means that the series has no seasonality. Alpha, Beta, and
+// Gamma are the smoothing factors of the level, trend and season of the Holt-Winters
+// model.
+//
+// If Confidence is greater than zero, each projected point also includes the bounds
+// of a band that is expected to contain the actual value with that probability.
+type ForecastOptions struct {
+	Model        ForecastModel
+	Interval     int
+	History      int
+	Horizon      int
+	SeasonLength int
+	Alpha        float64
+	Beta         float64
+	Gamma        float64
+	Confidence   float64
+}
+
+// zeroWhenEmpty returns true if an interval without values means that the result of a
+// function type over that interval is zero, rather than unknown.
+func zeroWhenEmpty(functionType FunctionType) bool {
+	switch functionType {
+	case Sum, Count, DistinctCount, Delta, Rate:
+		return true
+	}
+
+	return false
+}
+
+// Forecast projects the future values of a series, returning an array of {ts, value}
+// objects like Aggregate does. If a confidence level is requested, each object also
+// contains the `lower` and `upper` bounds of the confidence band.
+func (s *Series) Forecast(functionType FunctionType, options ForecastOptions) (interface{}, error) {
+	if options.Interval <= 0 || options.History <= 0 || options.Horizon <= 0 {
+		return nil, errors.New("The interval, history and horizon of a forecast must be greater than zero")
+	}
+
+	if options.Confidence < 0 || options.Confidence >= 1 {
+		return nil, errors.New("The confidence level of a forecast must be between zero and one")
+	}
+
+	end := int(time.Now().Unix()) / options.Interval * options.Interval
+	start := end - options.History*options.Interval
+
+	rows, err := s.buckets(functionType, start, options.Interval)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var fit func(step int) (float64, float64)
+
+	switch options.Model {
+	case HoltWintersForecast:
+		fit, err = holtWinters(rows, options, zeroWhenEmpty(functionType))
+
+	default:
+		fit, err = linearRegression(rows, options, zeroWhenEmpty(functionType))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// The z-score of a two-sided confidence level of a normal distribution
+	z := math.Sqrt2 * math.Erfinv(options.Confidence)
+
+	output := []interface{}{}
+
+	for step := 0; step < options.Horizon; step++ {
+		value, deviation := fit(step)
+
+		point := map[string]interface{}{
+			"ts":    end + step*options.Interval,
+			"value": value,
+		}
+
+		if options.Confidence > 0 {
+			point["lower"] = value - z*deviation
+			point["upper"] = value + z*deviation
+		}
+
+		output = append(output, point)
+	}
+
+	return interface{}(output), nil
+}
+
+// linearRegression fits a line to the complete intervals of a series by least squares.
+// It returns a function that projects the value of the interval that is step intervals
+// after the current one, along with the standard deviation of the prediction.
+func linearRegression(rows map[int]float64, options ForecastOptions, zeroWhenEmpty bool) (func(int) (float64, float64), error) {
+	xs := []float64{}
+	ys := []float64{}
+
+	for index := 0; index < options.History; index++ {
+		value, ok := rows[index*options.Interval]
+
+		if !ok && !zeroWhenEmpty {
+			continue
+		}
+
+		xs = append(xs, float64(index))
+		ys = append(ys, value)
+	}
+
+	n := float64(len(xs))
+
+	if len(xs) < 2 {
+		return nil, errors.New("A linear forecast requires values in at least two intervals")
+	}
+
+	meanX := sum(xs) / n
+	meanY := sum(ys) / n
+
+	sxx := 0.0
+	sxy := 0.0
+
+	for index := range xs {
+		sxx += (xs[index] - meanX) * (xs[index] - meanX)
+		sxy += (xs[index] - meanX) * (ys[index] - meanY)
+	}
+
+	slope := sxy / sxx
+	intercept := meanY - slope*meanX
+
+	// The standard error of the residuals; it can only be estimated from three or more
+	// values, since a line always fits two points exactly.
+	residual := 0.0
+
+	if len(xs) > 2 {
+		sse := 0.0
+
+		for index := range xs {
+			e := ys[index] - (intercept + slope*xs[index])
+			sse += e * e
+		}
+
+		residual = math.Sqrt(sse / (n - 2))
+	}
+
+	return func(step int) (float64, float64) {
+		x := float64(options.History + step)
+
+		return intercept + slope*x, residual * math.Sqrt(1+1/n+(x-meanX)*(x-meanX)/sxx)
+	}, nil
+}
+
+// holtWinters fits an additive Holt-Winters model to the complete intervals of a series.
+// Intervals without values are set to zero if zeroWhenEmpty is true; otherwise, they
+// are interpolated from their neighbours, the last value is carried forward, and the
+// intervals before the first value are ignored.
+//
+// It returns a function that projects the value of the interval that is step intervals
+// after the current one, along with the standard deviation of the prediction, which is
+// approximated from the one-step-ahead errors of the model, growing with the square
+// root of the number of steps.
+func holtWinters(rows map[int]float64, options ForecastOptions, zeroWhenEmpty bool) (func(int) (float64, float64), error) {
+	values := []float64{}
+
+	for index := 0; index < options.History; index++ {
+		value, ok := rows[index*options.Interval]
+
+		if !ok && !zeroWhenEmpty {
+			value = math.NaN()
+		}
+
+		values = append(values, value)
+	}
+
+	for len(values) > 0 && math.IsNaN(values[0]) {
+		values = values[1:]
+	}
+
+	for index := range values {
+		if !math.IsNaN(values[index]) {
+			continue
+		}
+
+		next := index + 1
+
+		for next < len(values) && math.IsNaN(values[next]) {
+			next++
+		}
+
+		if next == len(values) {
+			// Trailing intervals without values carry the last value forward
+			values[index] = values[index-1]
+		} else {
+			values[index] = values[index-1] + (values[next]-values[index-1])/float64(next-index+1)
+		}
+	}
+
+	season := options.SeasonLength
+
+	if season < 0 {
+		return nil, errors.New("The season length of a forecast cannot be negative")
+	}
+
+	if season > 0 && len(values) < 2*season {
+		return nil, errors.New(fmt.Sprintf("A Holt-Winters forecast with a season of %d intervals requires values in at least %d intervals", season, 2*season))
+	}
+
+	if len(values) < 2 {
+		return nil, errors.New("A Holt-Winters forecast requires values in at least two intervals")
+	}
+
+	for _, factor := range []float64{options.Alpha, options.Beta, options.Gamma} {
+		if factor < 0 || factor > 1 {
+			return nil, errors.New("The smoothing factors of a Holt-Winters forecast must be between zero and one")
+		}
+	}
+
+	var level, trend float64
+	var first int
+
+	seasonals := make([]float64, season)
+
+	if season > 0 {
+		level = sum(values[:season]) / float64(season)
+		trend = (sum(values[season:2*season])/float64(season) - level) / float64(season)
+
+		for index := 0; index < season; index++ {
+			seasonals[index] = values[index] - level
+		}
+
+		first = season
+	} else {
+		level = values[0]
+		trend = values[1] - values[0]
+		first = 1
+	}
+
+	seasonal := func(index int) float64 {
+		if season == 0 {
+			return 0.0
+		}
+
+		return seasonals[index%season]
+	}
+
+	squaredErrors := 0.0
+
+	for index := first; index < len(values); index++ {
+		value := values[index]
+		previousSeasonal := seasonal(index)
+
+		e := value - (level + trend + previousSeasonal)
+		squaredErrors += e * e
+
+		newLevel := options.Alpha*(value-previousSeasonal) + (1-options.Alpha)*(level+trend)
+		trend = options.Beta*(newLevel-level) + (1-options.Beta)*trend
+		level = newLevel
+
+		if season > 0 {
+			seasonals[index%season] = options.Gamma*(value-level) + (1-options.Gamma)*previousSeasonal
+		}
+	}
+
+	deviation := 0.0
+
+	if count := len(values) - first; count > 0 {
+		deviation = math.Sqrt(squaredErrors / float64(count))
+	}
+
+	last := len(values) - 1
+
+	return func(step int) (float64, float64) {
+		h := float64(step + 1)
+
+		return level + h*trend + seasonal(last+step+1), deviation * math.Sqrt(h)
+	}, nil
+}
@@ -0,0 +1,185 @@
+package aggregations
+
+import (
+	"math"
+	"testing"
+)
+
+// forecastTestRows returns the rows of a series aggregated in intervals of a minute,
+// with the given values; NaN stands for an interval without values.
+func forecastTestRows(values ...float64) map[int]float64 {
+	rows := map[int]float64{}
+
+	for index, value := range values {
+		if !math.IsNaN(value) {
+			rows[index*60] = value
+		}
+	}
+
+	return rows
+}
+
+func expectForecast(t *testing.T, name string, fit func(int) (float64, float64), step int, value, deviation float64) {
+	v, d := fit(step)
+
+	if math.Abs(v-value) > 1e-9 || math.Abs(d-deviation) > 1e-9 {
+		t.Errorf("%s, step %d: expected %g ± %g, got %g ± %g", name, step, value, deviation, v, d)
+	}
+}
+
+func TestLinearRegression(t *testing.T) {
+	nan := math.NaN()
+
+	tests := []struct {
+		name          string
+		values        []float64
+		zeroWhenEmpty bool
+		step          int
+		value         float64
+		deviation     float64
+	}{
+		{"exact line", []float64{1, 3, 5, 7, 9}, false, 0, 11, 0},
+		{"exact line, later step", []float64{1, 3, 5, 7, 9}, false, 3, 17, 0},
+		{"leading and trailing empty intervals", []float64{nan, nan, 5, 7, 9, 11, nan, nan}, false, 0, 17, 0},
+		{"empty interval in the middle", []float64{1, 3, nan, 7, 9}, false, 0, 11, 0},
+		{"empty intervals that count as zero", []float64{nan, 2, nan, 2}, true, 0, 2, 2},
+		{"noisy line", []float64{1, 3, 4, 7}, false, 0, 8.5, math.Sqrt(0.875)},
+	}
+
+	for _, test := range tests {
+		fit, err := linearRegression(forecastTestRows(test.values...), ForecastOptions{Interval: 60, History: len(test.values)}, test.zeroWhenEmpty)
+
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		expectForecast(t, test.name, fit, test.step, test.value, test.deviation)
+	}
+}
+
+func TestLinearRegressionRequiresTwoValues(t *testing.T) {
+	nan := math.NaN()
+
+	if _, err := linearRegression(forecastTestRows(nan, 5, nan), ForecastOptions{Interval: 60, History: 3}, false); err == nil {
+		t.Error("Expected an error for a single value")
+	}
+}
+
+func TestHoltWinters(t *testing.T) {
+	nan := math.NaN()
+
+	tests := []struct {
+		name          string
+		values        []float64
+		zeroWhenEmpty bool
+		options       ForecastOptions
+		step          int
+		value         float64
+		deviation     float64
+	}{
+		{
+			name:    "exact line",
+			values:  []float64{1, 3, 5, 7, 9},
+			options: ForecastOptions{Alpha: 0.5, Beta: 0.5},
+			step:    0,
+			value:   11,
+		},
+		{
+			name:    "exact line, later step",
+			values:  []float64{1, 3, 5, 7, 9},
+			options: ForecastOptions{Alpha: 0.5, Beta: 0.5},
+			step:    2,
+			value:   15,
+		},
+		{
+			// The leading interval is ignored, the one in the middle is interpolated to
+			// 4, and the trailing one carries 6 forward, which the level follows
+			// completely, while the trend stays at its initial value of 2; the model
+			// only misses the last interval, by 2.
+			name:      "leading, inner and trailing empty intervals",
+			values:    []float64{nan, 2, nan, 6, nan},
+			options:   ForecastOptions{Alpha: 1, Beta: 0},
+			step:      1,
+			value:     10,
+			deviation: math.Sqrt(4.0/3) * math.Sqrt(2),
+		},
+		{
+			name:    "interpolation over several intervals",
+			values:  []float64{0, nan, nan, 6, 8},
+			options: ForecastOptions{Alpha: 0.3, Beta: 0.7},
+			step:    0,
+			value:   10,
+		},
+		{
+			name:          "empty intervals that count as zero",
+			values:        []float64{4, nan, 0},
+			zeroWhenEmpty: true,
+			options:       ForecastOptions{Alpha: 0, Beta: 0},
+			step:          0,
+			value:         -8,
+			deviation:     math.Sqrt(16 / 2.0),
+		},
+		{
+			name:    "seasonal pattern",
+			values:  []float64{11, 9, 12, 8, 11, 9, 12, 8, 11, 9, 12, 8},
+			options: ForecastOptions{Alpha: 0.5, Beta: 0.5, Gamma: 0.5, SeasonLength: 4},
+			step:    0,
+			value:   11,
+		},
+		{
+			name:    "seasonal pattern, later step",
+			values:  []float64{11, 9, 12, 8, 11, 9, 12, 8, 11, 9, 12, 8},
+			options: ForecastOptions{Alpha: 0.5, Beta: 0.5, Gamma: 0.5, SeasonLength: 4},
+			step:    6,
+			value:   12,
+		},
+		{
+			name:    "seasonal pattern after an empty season",
+			values:  []float64{nan, nan, nan, nan, 11, 9, 12, 8, 11, 9, 12, 8},
+			options: ForecastOptions{Alpha: 0.2, Beta: 0.1, Gamma: 0.3, SeasonLength: 4},
+			step:    1,
+			value:   9,
+		},
+	}
+
+	for _, test := range tests {
+		test.options.Interval = 60
+		test.options.History = len(test.values)
+
+		fit, err := holtWinters(forecastTestRows(test.values...), test.options, test.zeroWhenEmpty)
+
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		expectForecast(t, test.name, fit, test.step, test.value, test.deviation)
+	}
+}
+
+func TestHoltWintersErrors(t *testing.T) {
+	nan := math.NaN()
+
+	tests := []struct {
+		name    string
+		values  []float64
+		options ForecastOptions
+	}{
+		{"a single value", []float64{nan, nan, 5}, ForecastOptions{Alpha: 0.5}},
+		{"no values", []float64{nan, nan}, ForecastOptions{Alpha: 0.5}},
+		{"fewer than two seasons", []float64{1, 2, 3, 4, 5}, ForecastOptions{SeasonLength: 3}},
+		{"a negative season", []float64{1, 2, 3, 4}, ForecastOptions{SeasonLength: -1}},
+		{"a smoothing factor above one", []float64{1, 2, 3, 4}, ForecastOptions{Alpha: 1.5}},
+		{"a negative smoothing factor", []float64{1, 2, 3, 4}, ForecastOptions{Gamma: -0.1}},
+	}
+
+	for _, test := range tests {
+		test.options.Interval = 60
+		test.options.History = len(test.values)
+
+		if _, err := holtWinters(forecastTestRows(test.values...), test.options, false); err == nil {
+			t.Errorf("%s: expected an error", test.name)
+		}
+	}
+}
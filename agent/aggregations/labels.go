@@ -0,0 +1,163 @@
+package aggregations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Labels
+//
+// Each value of a series can carry a set of labels, like `region=eu` and `channel=web`,
+// so that a single series can hold several dimensions of the same metric. Labels are
+// stored in the `labels` column as a JSON object whose keys are sorted, which makes the
+// text of each label predictable; a value can therefore be matched against a label by
+// looking for the JSON encoding of the label's name and value, without having to
+// decode the object in SQL.
+
+func validateLabelName(name string) error {
+	if seriesNameRegex.MatchString(name) {
+		return nil
+	}
+
+	return errors.New(fmt.Sprintf("Invalid label name `%s`. Label names must start with a letter or underscore and can only contain letters, underscores, and digits.", name))
+}
+
+// encodeLabels returns the representation of a set of labels stored in the database,
+// or nil if there are no labels.
+func encodeLabels(labels map[string]string) interface{} {
+	if len(labels) == 0 {
+		return nil
+	}
+
+	// encoding/json sorts the keys of maps
+	result, _ := json.Marshal(labels)
+
+	return string(result)
+}
+
+// labelCondition returns an SQL condition that matches the values that have a label.
+func labelCondition(name, value string) string {
+	encodedName, _ := json.Marshal(name)
+	encodedValue, _ := json.Marshal(value)
+
+	pattern := string(encodedName) + ":" + string(encodedValue)
+
+	// Escape the wildcards of GLOB, which, unlike LIKE, is case-sensitive
+	pattern = strings.NewReplacer("[", "[[]", "*", "[*]", "?", "[?]").Replace(pattern)
+
+	return "labels GLOB '*" + strings.Replace(pattern, "'", "''", -1) + "*'"
+}
+
+// source returns the SQL that stands for the values of the series in queries: the name
+// of its table or, if the series reads from a rollup tier, a subquery that returns the
+// rows of the tier. If the series has labels, the values are further restricted to
+// those that have all of them.
+func (s *Series) source() string {
+	table := s.Name
+	columns := "rowid AS rowid, ts, value, labels"
+
+	if len(s.tiers) > 1 {
+		table = "(" + s.tierSource(len(s.tiers)-1) + ")"
+		columns = "*"
+	}
+
+	if len(s.labels) == 0 {
+		return table
+	}
+
+	names := []string{}
+
+	for name := range s.labels {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	conditions := []string{}
+
+	for _, name := range names {
+		conditions = append(conditions, labelCondition(name, s.labels[name]))
+	}
+
+	return "(SELECT " + columns + " FROM " + table + " WHERE " + strings.Join(conditions, " AND ") + ")"
+}
+
+// WithLabels returns a copy of the series that is restricted to the values that have
+// the given labels, in addition to those of the series itself. Values pushed to the
+// copy are recorded with all those labels.
+func (s *Series) WithLabels(labels map[string]string) (*Series, error) {
+	result := &Series{
+		context: s.context,
+		Name:    s.Name,
+		labels:  map[string]string{},
+		tiers:   s.tiers,
+	}
+
+	for name, value := range s.labels {
+		result.labels[name] = value
+	}
+
+	for name, value := range labels {
+		if err := validateLabelName(name); err != nil {
+			return nil, err
+		}
+
+		result.labels[name] = value
+	}
+
+	return result, nil
+}
+
+// LabelValues returns the sorted values that a label takes in the series. Values
+// recorded without the label are ignored.
+func (s *Series) LabelValues(name string) ([]string, error) {
+	if err := validateLabelName(name); err != nil {
+		return nil, err
+	}
+
+	found := map[string]bool{}
+
+	rs, err := s.query("SELECT DISTINCT labels FROM ?? WHERE labels IS NOT NULL")
+
+	if err == io.EOF {
+		return []string{}, nil
+	}
+
+	for ; err == nil; err = rs.Next() {
+		var source string
+
+		if err := rs.Scan(&source); err != nil {
+			rs.Close()
+			return nil, err
+		}
+
+		labels := map[string]string{}
+
+		if err := json.Unmarshal([]byte(source), &labels); err != nil {
+			rs.Close()
+			return nil, err
+		}
+
+		if value, ok := labels[name]; ok {
+			found[value] = true
+		}
+	}
+
+	if err != io.EOF {
+		return nil, err
+	}
+
+	result := []string{}
+
+	for value := range found {
+		result = append(result, value)
+	}
+
+	sort.Strings(result)
+
+	return result, nil
+}
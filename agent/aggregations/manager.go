@@ -8,6 +8,7 @@ import (
 type Manager struct {
 	path         string
 	ttl          int
+	retention    []retentionPolicy
 	errorChannel chan error
 }
 
@@ -25,9 +26,16 @@ func Init(cfg config.ConfigInterface, errorChannel chan error) error {
 			ttl = -1
 		}
 
+		retention, err := parseRetentionPolicies(dataConfig.Retention)
+
+		if err != nil {
+			return err
+		}
+
 		manager = &Manager{
 			path:         *dataConfig.DataLocation,
 			ttl:          ttl,
+			retention:    retention,
 			errorChannel: errorChannel,
 		}
 
@@ -42,6 +50,10 @@ func Init(cfg config.ConfigInterface, errorChannel chan error) error {
 		c.Debugf("Writing data layer database to %s", manager.path)
 		c.Debugf("Default data layer TTL is set to %d", manager.ttl)
 
+		for _, policy := range manager.retention {
+			c.Debugf("Retention policy for `%s`: %s", policy.pattern, describeTiers(policy.tiers))
+		}
+
 		return nil
 	}
 
@@ -49,3 +61,13 @@ func Init(cfg config.ConfigInterface, errorChannel chan error) error {
 
 	return nil
 }
+
+// DataPath returns the path of the data layer's database, or an empty string if the
+// data layer has not been configured.
+func DataPath() string {
+	if manager == nil {
+		return ""
+	}
+
+	return manager.path
+}
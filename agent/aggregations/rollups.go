@@ -0,0 +1,537 @@
+package aggregations
+
+import (
+	"errors"
+	"fmt"
+	"github.com/telemetryapp/gotelemetry"
+	"github.com/telemetryapp/gotelemetry_agent/agent/config"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rollups
+//
+// A retention policy keeps the values of a series at decreasing levels of detail as
+// they age: for example, raw values for two days, one value per minute for 30 days, and
+// one value per hour for two years. Each tier except the raw one is stored in its own
+// table, named after the series and the tier's resolution in seconds, like `cpu-60`,
+// whose rows summarize the values recorded in each interval with each set of labels.
+//
+// A background job periodically rolls up the rows of the complete intervals of each tier
+// into the tier above it, and then removes the rows that are older than their tier's
+// retention period, but only once they have been rolled up into the next tier.
+//
+// Queries read from a tier by combining its rows with the rows of the finer tiers that
+// have not been rolled up yet, so that they always include the most recent values, as
+// well as the values that were pushed with an old timestamp after their interval had
+// been rolled up.
+
+// Struct retentionTier is a level of detail at which the values of a series are kept.
+// Both fields are expressed in seconds; a resolution of zero stands for the raw values,
+// and a keep of zero means that the values are kept forever.
+type retentionTier struct {
+	resolution int
+	keep       int
+}
+
+// Struct retentionPolicy is the list of tiers of the series whose names match a glob
+// pattern. The first tier always holds the raw values, and the others are sorted by
+// resolution, each being a multiple of the one before.
+type retentionPolicy struct {
+	pattern string
+	tiers   []retentionTier
+}
+
+// The shortest and longest time between two runs of the rollup job
+const minimumRollupInterval = time.Minute
+const maximumRollupInterval = time.Hour
+
+func parseRetentionPolicies(configs []config.RetentionConfig) ([]retentionPolicy, error) {
+	result := []retentionPolicy{}
+
+	for _, c := range configs {
+		if _, err := path.Match(c.Series, ""); err != nil || c.Series == "" {
+			return nil, errors.New(fmt.Sprintf("Invalid series pattern `%s` in the retention policies", c.Series))
+		}
+
+		policy := retentionPolicy{
+			pattern: c.Series,
+			tiers:   []retentionTier{retentionTier{}},
+		}
+
+		for index, tierConfig := range c.Tiers {
+			tier := retentionTier{}
+
+			if tierConfig.Resolution != "" {
+				resolution, err := config.ParseDuration(tierConfig.Resolution)
+
+				if err != nil {
+					return nil, err
+				}
+
+				if resolution < time.Second || resolution%time.Second != 0 {
+					return nil, errors.New(fmt.Sprintf("The resolution of the retention tiers of `%s` must be a whole number of seconds", c.Series))
+				}
+
+				tier.resolution = int(resolution / time.Second)
+			}
+
+			if tierConfig.Keep != "" {
+				keep, err := config.ParseDuration(tierConfig.Keep)
+
+				if err != nil {
+					return nil, err
+				}
+
+				if keep < time.Second {
+					return nil, errors.New(fmt.Sprintf("The retention tiers of `%s` must be kept for at least one second", c.Series))
+				}
+
+				tier.keep = int(keep / time.Second)
+			}
+
+			if tier.resolution == 0 {
+				if index > 0 {
+					return nil, errors.New(fmt.Sprintf("The raw tier of `%s` must be the first of its retention tiers", c.Series))
+				}
+
+				policy.tiers[0] = tier
+				continue
+			}
+
+			previous := policy.tiers[len(policy.tiers)-1].resolution
+
+			if tier.resolution <= previous || (previous > 0 && tier.resolution%previous != 0) {
+				return nil, errors.New(fmt.Sprintf("The resolution of each retention tier of `%s` must be a multiple of the resolution of the tier before it", c.Series))
+			}
+
+			policy.tiers = append(policy.tiers, tier)
+		}
+
+		result = append(result, policy)
+	}
+
+	return result, nil
+}
+
+// retentionTiers returns the tiers of the first retention policy that matches the name
+// of a series, or nil if there is none.
+func retentionTiers(name string) []retentionTier {
+	if manager == nil {
+		return nil
+	}
+
+	for _, policy := range manager.retention {
+		if ok, _ := path.Match(policy.pattern, name); ok {
+			return policy.tiers
+		}
+	}
+
+	return nil
+}
+
+// rollupOperation returns the SQL expression that computes a function type from the
+// rows of a rollup table, or false if the function type can only be computed from the
+// raw values.
+func rollupOperation(functionType FunctionType) (string, bool) {
+	switch functionType {
+	case Sum:
+		return "TOTAL(total)", true
+
+	case Avg:
+		return "TOTAL(total) / SUM(samples)", true
+
+	case Min:
+		return "MIN(minimum)", true
+
+	case Max:
+		return "MAX(maximum)", true
+
+	case Count:
+		return "TOTAL(samples)", true
+
+	case StdDev, Variance:
+		return "TOTAL(squares) / SUM(samples) - (TOTAL(total) / SUM(samples)) * (TOTAL(total) / SUM(samples))", true
+	}
+
+	return "", false
+}
+
+// finishRollupOperation applies the part of a function type that SQLite can't compute.
+func finishRollupOperation(functionType FunctionType, value float64) float64 {
+	switch functionType {
+	case StdDev, Variance:
+		// Rounding errors can make a variance that should be zero slightly negative
+		value = math.Max(value, 0)
+
+		if functionType == StdDev {
+			return math.Sqrt(value)
+		}
+	}
+
+	return value
+}
+
+// rollupTable returns the quoted name of the table that holds a tier of a series. The
+// name contains a hyphen, so that it can never clash with the name of a series.
+func rollupTable(name string, resolution int) string {
+	return `"` + name + "-" + strconv.Itoa(resolution) + `"`
+}
+
+func (s *Series) createRollupTable(resolution int) error {
+	table := rollupTable(s.Name, resolution)
+	index := rollupTable(s.Name+"-index", resolution)
+
+	if err := s.context.conn.Exec("CREATE TABLE IF NOT EXISTS " + table + " (ts INT NOT NULL, labels TEXT, samples INT, total FLOAT, minimum FLOAT, maximum FLOAT, squares FLOAT, rolled INT NOT NULL DEFAULT 0)"); err != nil {
+		return err
+	}
+
+	return s.context.conn.Exec("CREATE INDEX IF NOT EXISTS " + index + " ON " + table + " (ts)")
+}
+
+// prepareTiers creates the tables of the tiers of a retention policy, and adds to the
+// table of the raw values the column that records whether each value has been rolled
+// up into the first tier.
+func (s *Series) prepareTiers(tiers []retentionTier) error {
+	// Tables created before the series had a retention policy don't have the column
+	hasRolled, err := s.hasColumn("rolled")
+
+	if err != nil {
+		return err
+	}
+
+	if !hasRolled {
+		if err := s.exec("ALTER TABLE ?? ADD COLUMN rolled INT NOT NULL DEFAULT 0"); err != nil {
+			return err
+		}
+	}
+
+	if err := s.context.conn.Exec("CREATE INDEX IF NOT EXISTS \"" + s.Name + "-rolled\" ON " + s.Name + " (rolled, ts)"); err != nil {
+		return err
+	}
+
+	for _, t := range tiers[1:] {
+		if err := s.createRollupTable(t.resolution); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// withTier returns a copy of the series whose queries read from a tier of its retention
+// policy rather than from its raw values.
+func (s *Series) withTier(tiers []retentionTier, tier int) (*Series, error) {
+	if err := s.prepareTiers(tiers[:tier+1]); err != nil {
+		return nil, err
+	}
+
+	return &Series{
+		context: s.context,
+		Name:    s.Name,
+		labels:  s.labels,
+		tiers:   tiers[:tier+1],
+	}, nil
+}
+
+// tierTable returns the name of the table that holds a tier of the series.
+func (s *Series) tierTable(tier int) string {
+	if tier == 0 {
+		return s.Name
+	}
+
+	return rollupTable(s.Name, s.tiers[tier].resolution)
+}
+
+// tierRows returns a query that returns the rows stored in the table of a tier of the
+// series, in the format of the rollup tables. The `rolled` column tells whether each
+// row has been rolled up into the next tier.
+func (s *Series) tierRows(tier int) string {
+	if tier == 0 {
+		return "SELECT ts, labels, 1 AS samples, value AS total, value AS minimum, value AS maximum, value * value AS squares, rolled FROM " + s.tierTable(0)
+	}
+
+	return "SELECT ts, labels, samples, total, minimum, maximum, squares, rolled FROM " + s.tierTable(tier)
+}
+
+// tierSource returns a query that returns the rows of a tier of the series, in the
+// format of the rollup tables. The values that have not been rolled up into the tier
+// yet, including those pushed after their interval had been rolled up, come from the
+// rows of the tiers below that have not been rolled up into the next tier.
+func (s *Series) tierSource(tier int) string {
+	result := s.tierRows(tier)
+
+	for below := tier - 1; below >= 0; below-- {
+		result += " UNION ALL SELECT * FROM (" + s.tierRows(below) + ") WHERE rolled = 0"
+	}
+
+	return result
+}
+
+// bestTier returns the index of the tier from which a function type is best computed
+// over the values recorded from start onwards, grouped in intervals of the given number
+// of seconds, or zero if the raw values must be used. An interval of zero means that
+// the values are not grouped.
+//
+// The coarsest tier whose resolution divides both start and the interval is the fastest
+// to query, and its results are the same as those computed from the raw values, as long
+// as it goes back to start. If no tier fits, the raw values are used, unless they have
+// been removed by then, in which case the finest tier that goes back to start is the
+// best approximation.
+func bestTier(tiers []retentionTier, functionType FunctionType, start time.Time, interval int) int {
+	if len(tiers) < 2 {
+		return 0
+	}
+
+	if _, ok := rollupOperation(functionType); !ok {
+		return 0
+	}
+
+	since := int(time.Since(start) / time.Second)
+
+	covers := func(tier int) bool {
+		return tiers[tier].keep == 0 || since <= tiers[tier].keep
+	}
+
+	if interval > 0 {
+		for tier := len(tiers) - 1; tier > 0; tier-- {
+			resolution := tiers[tier].resolution
+
+			if interval%resolution == 0 && int(start.Unix())%resolution == 0 && covers(tier) {
+				return tier
+			}
+		}
+	}
+
+	for tier := range tiers {
+		if covers(tier) {
+			return tier
+		}
+	}
+
+	// Nothing goes back that far; use the tier that has the oldest values
+	return len(tiers) - 1
+}
+
+// requireRawValues returns an error if the raw values of the series recorded from
+// start onwards may have been removed by its retention policy. It is used by the
+// queries that can't be computed from the rollup tiers.
+func (s *Series) requireRawValues(start time.Time) error {
+	tiers := retentionTiers(s.Name)
+
+	if len(tiers) == 0 || tiers[0].keep == 0 || int(time.Since(start)/time.Second) <= tiers[0].keep {
+		return nil
+	}
+
+	keep := time.Duration(tiers[0].keep) * time.Second
+
+	return errors.New(fmt.Sprintf("This operation requires the raw values of the series `%s`, which are only kept for %s; use the `sum`, `avg`, `min`, `max`, `count`, `stddev`, or `variance` operation, or a shorter period", s.Name, keep))
+}
+
+// rollupTier returns a copy of the series that reads from the best tier for a query,
+// or nil if the query should use the raw values. Queries that can only be computed
+// from the raw values fail if those values may have been removed.
+func (s *Series) rollupTier(functionType FunctionType, start time.Time, interval int) (*Series, error) {
+	if _, ok := rollupOperation(functionType); !ok {
+		return nil, s.requireRawValues(start)
+	}
+
+	tiers := retentionTiers(s.Name)
+	tier := bestTier(tiers, functionType, start, interval)
+
+	if tier == 0 {
+		return nil, nil
+	}
+
+	return s.withTier(tiers, tier)
+}
+
+// Rollup updates the tiers of the series according to its retention policy, if any,
+// and removes the values that are past their retention period.
+//
+// Each tier is updated with the rows of the tier below that haven't been rolled up yet
+// and belong to complete intervals, which are then marked as rolled up. Since all the
+// rollup operations can combine several rows of the same interval, values pushed after
+// their interval had already been rolled up are simply rolled up into an additional row.
+func (s *Series) Rollup() error {
+	tiers := retentionTiers(s.Name)
+
+	if len(tiers) == 0 {
+		return nil
+	}
+
+	now := int(time.Now().Unix())
+
+	if len(tiers) > 1 {
+		series, err := s.withTier(tiers, len(tiers)-1)
+
+		if err != nil {
+			return err
+		}
+
+		for tier := 1; tier < len(tiers); tier++ {
+			resolution := tiers[tier].resolution
+
+			// Only complete intervals are rolled up
+			end := now / resolution * resolution
+
+			if err := s.context.conn.Exec("INSERT INTO "+rollupTable(s.Name, resolution)+" (ts, labels, samples, total, minimum, maximum, squares) "+
+				"SELECT ts / ? * ? AS interval, labels, SUM(samples), TOTAL(total), MIN(minimum), MAX(maximum), TOTAL(squares) FROM ("+series.tierRows(tier-1)+") "+
+				"WHERE rolled = 0 AND ts < ? GROUP BY interval, labels", resolution, resolution, end); err != nil {
+				return err
+			}
+
+			if err := s.context.conn.Exec("UPDATE "+series.tierTable(tier-1)+" SET rolled = 1 WHERE rolled = 0 AND ts < ?", end); err != nil {
+				return err
+			}
+		}
+	}
+
+	for tier, t := range tiers {
+		if t.keep == 0 {
+			continue
+		}
+
+		cutoff := now - t.keep
+
+		// Values can only be removed once they have been rolled up into the next tier
+		condition := ""
+
+		if tier < len(tiers)-1 {
+			condition = " AND rolled = 1"
+		}
+
+		table := s.Name
+
+		if tier > 0 {
+			table = rollupTable(s.Name, t.resolution)
+		}
+
+		if err := s.context.conn.Exec("DELETE FROM "+table+" WHERE ts < ?"+condition, cutoff); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// StartRollups runs the rollup job in the background, at an interval that matches the
+// finest resolution of the retention policies. It does nothing if the data layer is not
+// available or there are no retention policies.
+func StartRollups() {
+	if manager == nil || len(manager.retention) == 0 {
+		return
+	}
+
+	m := manager
+	interval := maximumRollupInterval
+
+	for _, policy := range m.retention {
+		for _, tier := range policy.tiers[1:] {
+			if resolution := time.Duration(tier.resolution) * time.Second; resolution < interval {
+				interval = resolution
+			}
+		}
+	}
+
+	if interval < minimumRollupInterval {
+		interval = minimumRollupInterval
+	}
+
+	go func() {
+		for {
+			m.rollup()
+
+			time.Sleep(interval)
+		}
+	}()
+}
+
+// rollup updates the tiers of all the series that have a retention policy. Each series
+// is updated in its own transaction, so that an error only affects one series.
+func (m *Manager) rollup() {
+	context, err := GetContext()
+
+	if err != nil {
+		m.errorChannel <- err
+		return
+	}
+
+	names, err := ListSeries(context)
+
+	context.Close()
+
+	if err != nil {
+		m.errorChannel <- err
+		return
+	}
+
+	for _, name := range names {
+		if retentionTiers(name) == nil {
+			continue
+		}
+
+		if err := rollupSeries(name); err != nil {
+			m.errorChannel <- gotelemetry.NewError(500, fmt.Sprintf("Data Manager -> Unable to roll up the series `%s`: %s", name, err))
+		}
+	}
+}
+
+func rollupSeries(name string) error {
+	context, err := GetContext()
+
+	if err != nil {
+		return err
+	}
+
+	defer context.Close()
+
+	// Event series don't have a retention policy
+	isEventSeries, err := (&Series{context: context, Name: name}).hasColumn("event")
+
+	if err != nil || isEventSeries {
+		return err
+	}
+
+	if err := context.Begin(); err != nil {
+		return err
+	}
+
+	series, err := GetSeries(context, name)
+
+	if err == nil {
+		err = series.Rollup()
+	}
+
+	if err != nil {
+		context.SetError()
+	}
+
+	return err
+}
+
+// describeTiers returns a description of a retention policy for the debug log.
+func describeTiers(tiers []retentionTier) string {
+	result := []string{}
+
+	for _, tier := range tiers {
+		resolution := "raw"
+
+		if tier.resolution > 0 {
+			resolution = (time.Duration(tier.resolution) * time.Second).String()
+		}
+
+		keep := "forever"
+
+		if tier.keep > 0 {
+			keep = (time.Duration(tier.keep) * time.Second).String()
+		}
+
+		result = append(result, resolution+" for "+keep)
+	}
+
+	return strings.Join(result, ", ")
+}
@@ -0,0 +1,148 @@
+package aggregations
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/config"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRetentionPolicies(t *testing.T) {
+	policies, err := parseRetentionPolicies([]config.RetentionConfig{
+		{
+			Series: "cpu.*",
+			Tiers: []config.RetentionTierConfig{
+				{Keep: "2d"},
+				{Resolution: "1m", Keep: "30d"},
+				{Resolution: "1h"},
+			},
+		},
+		{
+			Series: "*",
+			Tiers: []config.RetentionTierConfig{
+				{Resolution: "5m", Keep: "1w"},
+			},
+		},
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []retentionPolicy{
+		{
+			pattern: "cpu.*",
+			tiers: []retentionTier{
+				{resolution: 0, keep: 2 * 86400},
+				{resolution: 60, keep: 30 * 86400},
+				{resolution: 3600, keep: 0},
+			},
+		},
+		{
+			pattern: "*",
+			tiers: []retentionTier{
+				{resolution: 0, keep: 0},
+				{resolution: 300, keep: 7 * 86400},
+			},
+		},
+	}
+
+	if !reflect.DeepEqual(policies, expected) {
+		t.Errorf("Expected %#v, got %#v", expected, policies)
+	}
+}
+
+func TestParseRetentionPoliciesErrors(t *testing.T) {
+	tests := map[string][]config.RetentionTierConfig{
+		"":      {{Keep: "1d"}},
+		"[":     {{Keep: "1d"}},
+		"bad-1": {{Keep: "tomorrow"}},
+		"bad-2": {{Resolution: "1x"}},
+		"bad-3": {{Resolution: "500ms"}},
+		"bad-4": {{Resolution: "1.5s"}},
+		"bad-5": {{Resolution: "1m", Keep: "500ms"}},
+		"bad-6": {{Resolution: "1m"}, {Keep: "1d"}},
+		"bad-7": {{Resolution: "1h"}, {Resolution: "1m"}},
+		"bad-8": {{Resolution: "1m"}, {Resolution: "1m"}},
+		"bad-9": {{Resolution: "1m"}, {Resolution: "90s"}},
+	}
+
+	for pattern, tiers := range tests {
+		if _, err := parseRetentionPolicies([]config.RetentionConfig{{Series: pattern, Tiers: tiers}}); err == nil {
+			t.Errorf("%s: expected an error for %#v", pattern, tiers)
+		}
+	}
+}
+
+func TestBestTier(t *testing.T) {
+	tiers := []retentionTier{
+		{resolution: 0, keep: 2 * 86400},
+		{resolution: 60, keep: 30 * 86400},
+		{resolution: 3600, keep: 0},
+	}
+
+	hour := time.Now().Truncate(time.Hour)
+
+	tests := []struct {
+		name         string
+		tiers        []retentionTier
+		functionType FunctionType
+		start        time.Time
+		interval     int
+		expected     int
+	}{
+		{"no retention policy", nil, Sum, hour.Add(-time.Hour), 3600, 0},
+		{"raw values only", tiers[:1], Sum, hour.Add(-time.Hour), 3600, 0},
+		{"hourly intervals", tiers, Sum, hour.Add(-24 * time.Hour), 3600, 2},
+		{"minute intervals", tiers, Avg, hour.Add(-24 * time.Hour), 60, 1},
+		{"intervals that aren't a multiple of a resolution", tiers, Max, hour.Add(-time.Hour), 90, 0},
+		{"start that isn't aligned with the hour", tiers, Sum, hour.Add(-90 * time.Minute), 3600, 1},
+		{"start that isn't aligned with any resolution", tiers, Sum, hour.Add(-time.Hour - time.Second), 3600, 0},
+		{"no intervals while the raw values are kept", tiers, Count, hour.Add(-time.Hour), 0, 0},
+		{"no intervals after the raw values are removed", tiers, Count, time.Now().Add(-3 * 24 * time.Hour), 0, 1},
+		{"minute tier removed", tiers, Sum, hour.Add(-60 * 24 * time.Hour), 60, 2},
+		{"function that needs the raw values", tiers, Median, hour.Add(-24 * time.Hour), 3600, 0},
+		{"function that needs the raw values, past their retention", tiers, P90, hour.Add(-60 * 24 * time.Hour), 3600, 0},
+		{
+			"nothing goes back far enough",
+			[]retentionTier{{resolution: 0, keep: 3600}, {resolution: 60, keep: 86400}},
+			Sum, hour.Add(-7 * 24 * time.Hour), 3600, 1,
+		},
+	}
+
+	for _, test := range tests {
+		if result := bestTier(test.tiers, test.functionType, test.start, test.interval); result != test.expected {
+			t.Errorf("%s: expected tier %d, got %d", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestRequireRawValues(t *testing.T) {
+	defer func(m *Manager) { manager = m }(manager)
+
+	manager = &Manager{
+		retention: []retentionPolicy{
+			{pattern: "short", tiers: []retentionTier{{keep: 3600}, {resolution: 60}}},
+			{pattern: "forever", tiers: []retentionTier{{}, {resolution: 60}}},
+		},
+	}
+
+	tests := []struct {
+		series string
+		start  time.Time
+		fails  bool
+	}{
+		{"short", time.Now().Add(-30 * time.Minute), false},
+		{"short", time.Now().Add(-2 * time.Hour), true},
+		{"forever", time.Now().Add(-365 * 24 * time.Hour), false},
+		{"unmatched", time.Now().Add(-365 * 24 * time.Hour), false},
+	}
+
+	for _, test := range tests {
+		err := (&Series{Name: test.series}).requireRawValues(test.start)
+
+		if (err != nil) != test.fails {
+			t.Errorf("%s since %s: unexpected result %v", test.series, test.start, err)
+		}
+	}
+}
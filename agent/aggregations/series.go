@@ -3,6 +3,7 @@ package aggregations
 import (
 	"errors"
 	"fmt"
+	"io"
 	"regexp"
 	"time"
 )
@@ -10,16 +11,85 @@ import (
 type FunctionType int
 
 const (
-	Sum   FunctionType = iota
-	Avg   FunctionType = iota
-	Min   FunctionType = iota
-	Max   FunctionType = iota
-	Count FunctionType = iota
+	Sum           FunctionType = iota
+	Avg           FunctionType = iota
+	Min           FunctionType = iota
+	Max           FunctionType = iota
+	Count         FunctionType = iota
+	Median        FunctionType = iota
+	P90           FunctionType = iota
+	P95           FunctionType = iota
+	P99           FunctionType = iota
+	StdDev        FunctionType = iota
+	Variance      FunctionType = iota
+	First         FunctionType = iota
+	Last          FunctionType = iota
+	DistinctCount FunctionType = iota
+	Delta         FunctionType = iota
+	Rate          FunctionType = iota
 )
 
+var functionTypeNames = map[string]FunctionType{
+	"sum":            Sum,
+	"avg":            Avg,
+	"min":            Min,
+	"max":            Max,
+	"count":          Count,
+	"median":         Median,
+	"p50":            Median,
+	"p90":            P90,
+	"p95":            P95,
+	"p99":            P99,
+	"stddev":         StdDev,
+	"variance":       Variance,
+	"first":          First,
+	"last":           Last,
+	"distinct_count": DistinctCount,
+	"delta":          Delta,
+	"rate":           Rate,
+}
+
+// GetFunctionType returns the function type that corresponds to the name of an
+// operation, as used by the `op` property of $compute and $aggregate.
+func GetFunctionType(name string) (FunctionType, error) {
+	if result, ok := functionTypeNames[name]; ok {
+		return result, nil
+	}
+
+	return Sum, errors.New(fmt.Sprintf("Unknown operation %s", name))
+}
+
+// sqlOperation returns the SQL expression that computes a function type, or false if
+// the function type must be computed in Go by computeValues.
+func sqlOperation(functionType FunctionType) (string, bool) {
+	switch functionType {
+	case Sum:
+		return "TOTAL(value)", true
+
+	case Avg:
+		return "AVG(value)", true
+
+	case Min:
+		return "MIN(value)", true
+
+	case Max:
+		return "MAX(value)", true
+
+	case Count:
+		return "COUNT(value)", true
+
+	case DistinctCount:
+		return "COUNT(DISTINCT value)", true
+	}
+
+	return "", false
+}
+
 type Series struct {
 	context *Context
 	Name    string
+	labels  map[string]string
+	tiers   []retentionTier
 }
 
 var cachedSeries = map[string]*Series{}
@@ -45,13 +115,46 @@ func GetSeries(context *Context, name string) (*Series, error) {
 	return result, nil
 }
 
+// ListSeries returns the sorted names of all the series stored in the data layer.
+func ListSeries(context *Context) ([]string, error) {
+	result := []string{}
+
+	rs, err := context.conn.Query("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+
+	if err == io.EOF {
+		return result, nil
+	}
+
+	for ; err == nil; err = rs.Next() {
+		var name string
+
+		if err := rs.Scan(&name); err != nil {
+			rs.Close()
+			return nil, err
+		}
+
+		// Skip the tables that aren't series, like the key/value store
+		if seriesNameRegex.MatchString(name) {
+			result = append(result, name)
+		}
+	}
+
+	if err != io.EOF {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Push appends a value to the series. If the series was obtained from WithLabels, the
+// value is recorded with its labels.
 func (s *Series) Push(timestamp *time.Time, value float64) error {
 	if timestamp == nil {
 		timestamp = &time.Time{}
 		*timestamp = time.Now()
 	}
 
-	return s.exec("INSERT INTO ?? (ts, value) VALUES (?, ?)", *timestamp, value)
+	return s.exec("INSERT INTO ?? (ts, value, labels) VALUES (?, ?, ?)", *timestamp, value, encodeLabels(s.labels))
 }
 
 func (s *Series) last() (map[string]interface{}, error) {
@@ -89,105 +192,182 @@ func (s *Series) Pop(shouldDelete bool) (map[string]interface{}, error) {
 }
 
 func (s *Series) Compute(functionType FunctionType, start, end *time.Time) (float64, error) {
-	var operation string
+	if start == nil {
+		start = &time.Time{}
+		*start = time.Unix(0, 0)
+	}
+
+	if end == nil {
+		end = &time.Time{}
+		*end = time.Now()
+	}
 
 	switch functionType {
-	case Sum:
-		operation = "TOTAL"
+	case Delta:
+		result, _, err := s.Change(*start, *end, true)
 
-	case Avg:
-		operation = "AVG"
+		return result, err
 
-	case Min:
-		operation = "MIN"
-
-	case Max:
-		operation = "MAX"
+	case Rate:
+		return s.Rate(*start, *end, true)
+	}
 
-	case Count:
-		operation = "COUNT"
+	rollup, err := s.rollupTier(functionType, *start, 0)
 
-	default:
-		return 0.0, errors.New(fmt.Sprintf("Unknown operation %d", functionType))
+	if err != nil {
+		return 0.0, err
 	}
 
-	if start == nil {
-		start = &time.Time{}
-		*start = time.Unix(0, 0)
+	if rollup != nil {
+		operation, _ := rollupOperation(functionType)
+
+		row, err := rollup.fetchRow("SELECT CAST("+operation+" AS FLOAT) AS result FROM ?? WHERE ts BETWEEN ? AND ?", *start, *end)
+
+		if err != nil {
+			return 0.0, err
+		}
+
+		if result, ok := row["result"].(float64); ok {
+			return finishRollupOperation(functionType, result), nil
+		}
+
+		return 0.0, nil
 	}
 
-	if end == nil {
-		end = &time.Time{}
-		*end = time.Now()
+	if operation, ok := sqlOperation(functionType); ok {
+		row, err := s.fetchRow("SELECT CAST("+operation+" AS FLOAT) AS result FROM ?? WHERE ts BETWEEN ? AND ?", *start, *end)
+
+		if err != nil {
+			return 0.0, err
+		}
+
+		// Operations like AVG return NULL if there are no values
+		if result, ok := row["result"].(float64); ok {
+			return result, nil
+		}
+
+		return 0.0, nil
 	}
 
-	row, err := s.fetchRow("SELECT CAST("+operation+"(value) AS FLOAT) AS result FROM ?? WHERE ts BETWEEN ? AND ?", *start, *end)
+	values, err := s.values("SELECT 0, value FROM ?? WHERE ts BETWEEN ? AND ? ORDER BY ts, rowid", *start, *end)
 
 	if err != nil {
 		return 0.0, err
 	}
 
-	return row["result"].(float64), nil
+	return computeValues(functionType, values[0])
 }
 
-func (s *Series) Aggregate(functionType FunctionType, interval, count int) (interface{}, error) {
-	var operation string
+// values runs a query that returns pairs of integer keys and values, and groups the
+// values by key, preserving the order in which they are returned.
+func (s *Series) values(query string, args ...interface{}) (map[int][]float64, error) {
+	result := map[int][]float64{}
 
-	switch functionType {
-	case Sum:
-		operation = "TOTAL"
+	rs, err := s.query(query, args...)
 
-	case Avg:
-		operation = "AVG"
+	if err == io.EOF {
+		return result, nil
+	}
 
-	case Min:
-		operation = "MIN"
+	for ; err == nil; err = rs.Next() {
+		var key int
+		var value float64
 
-	case Max:
-		operation = "MAX"
+		if err := rs.Scan(&key, &value); err != nil {
+			rs.Close()
+			return nil, err
+		}
 
-	case Count:
-		operation = "COUNT"
+		result[key] = append(result[key], value)
+	}
 
-	default:
-		return nil, errors.New(fmt.Sprintf("Unknown operation %d", functionType))
+	if err != io.EOF {
+		return nil, err
 	}
 
+	return result, nil
+}
+
+func (s *Series) Aggregate(functionType FunctionType, interval, count int) (interface{}, error) {
 	start := int(time.Now().Add(-time.Duration(interval*count)*time.Second).Unix()) / interval * interval
 
-	rs, err := s.query("SELECT (ts - ?) / ? * ? AS interval, "+operation+"(value) AS result FROM ?? WHERE ts >= ? GROUP BY interval", start, interval, interval, start)
+	rows, err := s.buckets(functionType, start, interval)
 
 	if err != nil {
 		return nil, err
 	}
 
+	output := []interface{}{}
+
+	for index := 0; index < count; index++ {
+		t := index * interval
+		ts := start + t
+
+		output = append(output, map[string]interface{}{"ts": ts, "value": rows[t]})
+	}
+
+	return interface{}(output), nil
+}
+
+// buckets applies a function type to the values recorded from start onwards, grouped
+// in intervals of the given number of seconds. The result maps the offset of each
+// interval from start to its value; intervals without any values are omitted.
+func (s *Series) buckets(functionType FunctionType, start, interval int) (map[int]float64, error) {
 	rows := map[int]float64{}
 
-	for rs.Next() == nil {
-		var index int
-		var value float64
+	rollup, err := s.rollupTier(functionType, time.Unix(int64(start), 0), interval)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if rollup != nil {
+		operation, _ := rollupOperation(functionType)
 
-		err := rs.Scan(&index, &value)
+		values, err := rollup.values("SELECT (ts - ?) / ? * ? AS interval, CAST("+operation+" AS FLOAT) AS result FROM ?? WHERE ts >= ? GROUP BY interval", start, interval, interval, start)
 
 		if err != nil {
 			return nil, err
 		}
 
-		rows[index] = value
-	}
+		for index, value := range values {
+			rows[index] = finishRollupOperation(functionType, value[0])
+		}
+	} else if functionType == Delta || functionType == Rate {
+		changes, err := s.aggregateChanges(start, interval, functionType == Rate)
 
-	output := []interface{}{}
+		if err != nil {
+			return nil, err
+		}
 
-	for index := 0; index < count; index++ {
-		t := index * interval
-		ts := start + t
+		rows = changes
+	} else if operation, ok := sqlOperation(functionType); ok {
+		values, err := s.values("SELECT (ts - ?) / ? * ? AS interval, CAST("+operation+" AS FLOAT) AS result FROM ?? WHERE ts >= ? GROUP BY interval", start, interval, interval, start)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for index, value := range values {
+			rows[index] = value[0]
+		}
+	} else {
+		values, err := s.values("SELECT (ts - ?) / ? * ? AS interval, value FROM ?? WHERE ts >= ? ORDER BY ts, rowid", start, interval, interval, start)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for index, value := range values {
+			result, err := computeValues(functionType, value)
 
-		if value, ok := rows[t]; ok {
-			output = append(output, map[string]interface{}{"ts": ts, "value": value})
-		} else {
-			output = append(output, map[string]interface{}{"ts": ts, "value": value})
+			if err != nil {
+				return nil, err
+			}
+
+			rows[index] = result
 		}
 	}
 
-	return interface{}(output), nil
+	return rows, nil
 }
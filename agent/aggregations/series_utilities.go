@@ -4,6 +4,7 @@ import (
 	"code.google.com/p/go-sqlite/go1/sqlite3"
 	"errors"
 	"fmt"
+	"io"
 	"strings"
 )
 
@@ -26,6 +27,7 @@ func createSeries(context *Context, name string) error {
 	}
 
 	cachedSeries[name] = result
+	context.createdSeries = append(context.createdSeries, name)
 
 	return nil
 }
@@ -34,8 +36,11 @@ func (s *Series) prepQuery(query string) string {
 	return strings.Replace(query, "??", s.Name, -1)
 }
 
+// query runs a query in which "??" stands for the values of the series. If the series
+// was obtained from WithLabels, "??" is replaced by a subquery that only returns the
+// values that have its labels.
 func (s *Series) query(query string, values ...interface{}) (*sqlite3.Stmt, error) {
-	return s.context.conn.Query(s.prepQuery(query), values...)
+	return s.context.conn.Query(strings.Replace(query, "??", s.source(), -1), values...)
 }
 
 func (s *Series) exec(query string, values ...interface{}) error {
@@ -59,7 +64,7 @@ func (s *Series) fetchRow(query string, values ...interface{}) (sqlite3.RowMap,
 }
 
 func (s *Series) createTable() error {
-	if err := s.exec("CREATE TABLE IF NOT EXISTS ?? (ts INT NOT NULL, value FLOAT)"); err != nil {
+	if err := s.exec("CREATE TABLE IF NOT EXISTS ?? (ts INT NOT NULL, value FLOAT, labels TEXT)"); err != nil {
 		return err
 	}
 
@@ -67,5 +72,56 @@ func (s *Series) createTable() error {
 		return err
 	}
 
+	isEventSeries, err := s.hasColumn("event")
+
+	if err != nil {
+		return err
+	}
+
+	if isEventSeries {
+		return errors.New(fmt.Sprintf("The series `%s` is an event series, and can only be used with the event functions.", s.Name))
+	}
+
+	// Tables created by earlier versions of the agent don't have labels
+	hasLabels, err := s.hasColumn("labels")
+
+	if err != nil {
+		return err
+	}
+
+	if !hasLabels {
+		if err := s.exec("ALTER TABLE ?? ADD COLUMN labels TEXT"); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
+
+func (s *Series) hasColumn(name string) (bool, error) {
+	rs, err := s.context.conn.Query(s.prepQuery("PRAGMA table_info(??)"))
+
+	if err == io.EOF {
+		return false, nil
+	}
+
+	for ; err == nil; err = rs.Next() {
+		row := sqlite3.RowMap{}
+
+		if err := rs.Scan(row); err != nil {
+			rs.Close()
+			return false, err
+		}
+
+		if row["name"] == name {
+			rs.Close()
+			return true, nil
+		}
+	}
+
+	if err != io.EOF {
+		return false, err
+	}
+
+	return false, nil
+}
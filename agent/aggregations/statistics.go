@@ -0,0 +1,106 @@
+package aggregations
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// computeValues applies a function type to a list of values sorted by timestamp.
+// It supports all the function types, but is only used for those that SQLite can't
+// compute on its own. Like SQLite's TOTAL(), it returns zero for an empty list.
+func computeValues(functionType FunctionType, values []float64) (float64, error) {
+	if len(values) == 0 {
+		return 0.0, nil
+	}
+
+	switch functionType {
+	case Sum:
+		return sum(values), nil
+
+	case Avg:
+		return sum(values) / float64(len(values)), nil
+
+	case Min:
+		return percentile(values, 0), nil
+
+	case Max:
+		return percentile(values, 100), nil
+
+	case Count:
+		return float64(len(values)), nil
+
+	case Median:
+		return percentile(values, 50), nil
+
+	case P90:
+		return percentile(values, 90), nil
+
+	case P95:
+		return percentile(values, 95), nil
+
+	case P99:
+		return percentile(values, 99), nil
+
+	case StdDev:
+		return math.Sqrt(variance(values)), nil
+
+	case Variance:
+		return variance(values), nil
+
+	case First:
+		return values[0], nil
+
+	case Last:
+		return values[len(values)-1], nil
+
+	case DistinctCount:
+		distinct := map[float64]bool{}
+
+		for _, value := range values {
+			distinct[value] = true
+		}
+
+		return float64(len(distinct)), nil
+	}
+
+	return 0.0, errors.New(fmt.Sprintf("The operation %d cannot be computed over a list of values", functionType))
+}
+
+func sum(values []float64) float64 {
+	result := 0.0
+
+	for _, value := range values {
+		result += value
+	}
+
+	return result
+}
+
+// variance returns the population variance of a list of values.
+func variance(values []float64) float64 {
+	mean := sum(values) / float64(len(values))
+	result := 0.0
+
+	for _, value := range values {
+		result += (value - mean) * (value - mean)
+	}
+
+	return result / float64(len(values))
+}
+
+// percentile returns the p-th percentile of a list of values, interpolating linearly
+// between the two closest ranks when the percentile falls between them.
+func percentile(values []float64, p float64) float64 {
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+
+	sort.Float64s(sorted)
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
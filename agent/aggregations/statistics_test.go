@@ -0,0 +1,98 @@
+package aggregations
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		values   []float64
+		p        float64
+		expected float64
+	}{
+		{[]float64{7}, 50, 7},
+		{[]float64{3, 1, 4, 2}, 0, 1},
+		{[]float64{3, 1, 4, 2}, 100, 4},
+		{[]float64{3, 1, 4, 2}, 50, 2.5},
+		{[]float64{3, 1, 4, 2}, 90, 3.7},
+		{[]float64{5, 1, 3}, 50, 3},
+		{[]float64{10, 20, 30, 40, 50, 60, 70, 80, 90, 100, 110}, 95, 105},
+		{[]float64{-1, -5, 2}, 25, -3},
+	}
+
+	for _, test := range tests {
+		if result := percentile(test.values, test.p); math.Abs(result-test.expected) > 1e-9 {
+			t.Errorf("P%g of %v: expected %g, got %g", test.p, test.values, test.expected, result)
+		}
+	}
+}
+
+func TestPercentileDoesNotSortItsInput(t *testing.T) {
+	values := []float64{3, 1, 2}
+
+	percentile(values, 50)
+
+	if !reflect.DeepEqual(values, []float64{3, 1, 2}) {
+		t.Errorf("The input was modified: %v", values)
+	}
+}
+
+func TestVariance(t *testing.T) {
+	tests := []struct {
+		values   []float64
+		expected float64
+	}{
+		{[]float64{5}, 0},
+		{[]float64{3, 3, 3}, 0},
+		{[]float64{2, 4, 4, 4, 5, 5, 7, 9}, 4},
+		{[]float64{1, 2, 3, 4}, 1.25},
+		{[]float64{-2, 2}, 4},
+	}
+
+	for _, test := range tests {
+		if result := variance(test.values); math.Abs(result-test.expected) > 1e-9 {
+			t.Errorf("Variance of %v: expected %g, got %g", test.values, test.expected, result)
+		}
+	}
+}
+
+func TestComputeValues(t *testing.T) {
+	values := []float64{4, 1, 4, 3}
+
+	tests := map[FunctionType]float64{
+		Sum:           12,
+		Avg:           3,
+		Min:           1,
+		Max:           4,
+		Count:         4,
+		Median:        3.5,
+		Variance:      1.5,
+		StdDev:        math.Sqrt(1.5),
+		First:         4,
+		Last:          3,
+		DistinctCount: 3,
+	}
+
+	for functionType, expected := range tests {
+		result, err := computeValues(functionType, values)
+
+		if err != nil {
+			t.Errorf("Function type %d: %s", functionType, err)
+			continue
+		}
+
+		if math.Abs(result-expected) > 1e-9 {
+			t.Errorf("Function type %d: expected %g, got %g", functionType, expected, result)
+		}
+	}
+
+	if result, err := computeValues(Avg, []float64{}); err != nil || result != 0 {
+		t.Errorf("Expected zero for an empty list, got %g, %v", result, err)
+	}
+
+	if _, err := computeValues(Rate, values); err == nil {
+		t.Error("Expected an error for a function type that needs timestamps")
+	}
+}
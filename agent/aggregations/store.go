@@ -0,0 +1,155 @@
+package aggregations
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// The name of the table that holds the key/value store. It contains a hyphen so that
+// it can never clash with the name of a series, and must therefore always be quoted.
+const storeTable = `"key-value"`
+
+// Struct Store is a persistent key/value store kept in the same database as the
+// series. Values are arbitrary JSON documents, and can optionally expire after a
+// certain amount of time; expired keys behave as if they had been deleted.
+//
+// Like series, the store works within the transaction of its context, so its changes
+// are rolled back if an error occurs.
+type Store struct {
+	context *Context
+}
+
+func GetStore(context *Context) (*Store, error) {
+	result := &Store{
+		context: context,
+	}
+
+	if err := result.createTable(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (s *Store) createTable() error {
+	if err := s.context.conn.Exec("CREATE TABLE IF NOT EXISTS " + storeTable + " (key TEXT PRIMARY KEY, value TEXT NOT NULL, expires INT)"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// expiry returns the time at which a key set now with the given TTL expires, or nil if
+// the TTL is zero.
+func expiry(ttl time.Duration) interface{} {
+	if ttl <= 0 {
+		return nil
+	}
+
+	return time.Now().Add(ttl).Unix()
+}
+
+// purge removes the expired keys from the store.
+func (s *Store) purge() error {
+	return s.context.conn.Exec("DELETE FROM "+storeTable+" WHERE expires IS NOT NULL AND expires <= ?", time.Now().Unix())
+}
+
+// Get returns the value of a key, and false if the key doesn't exist or has expired.
+func (s *Store) Get(key string) (interface{}, bool, error) {
+	rs, err := s.context.conn.Query("SELECT value FROM "+storeTable+" WHERE key = ? AND (expires IS NULL OR expires > ?)", key, time.Now().Unix())
+
+	if err == io.EOF {
+		return nil, false, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	defer rs.Close()
+
+	var source string
+
+	if err := rs.Scan(&source); err != nil {
+		return nil, false, err
+	}
+
+	var result interface{}
+
+	if err := json.Unmarshal([]byte(source), &result); err != nil {
+		return nil, false, errors.New(fmt.Sprintf("The value of the key `%s` is not valid JSON: %s", key, err))
+	}
+
+	return result, true, nil
+}
+
+// Set assigns a value to a key. If ttl is greater than zero, the key expires after
+// that amount of time; otherwise, it never expires.
+func (s *Store) Set(key string, value interface{}, ttl time.Duration) error {
+	source, err := json.Marshal(value)
+
+	if err != nil {
+		return errors.New(fmt.Sprintf("Unable to encode the value of the key `%s`: %s", key, err))
+	}
+
+	if err := s.purge(); err != nil {
+		return err
+	}
+
+	return s.context.conn.Exec("INSERT OR REPLACE INTO "+storeTable+" (key, value, expires) VALUES (?, ?, ?)", key, string(source), expiry(ttl))
+}
+
+// Increment adds an amount to the numeric value of a key, which is treated as zero if
+// the key doesn't exist, and returns the result. If ttl is greater than zero, the key
+// expires after that amount of time; otherwise, it keeps its current expiry, if any.
+func (s *Store) Increment(key string, amount float64, ttl time.Duration) (float64, error) {
+	if err := s.purge(); err != nil {
+		return 0.0, err
+	}
+
+	value, ok, err := s.Get(key)
+
+	if err != nil {
+		return 0.0, err
+	}
+
+	current := 0.0
+
+	if ok {
+		if current, ok = value.(float64); !ok {
+			return 0.0, errors.New(fmt.Sprintf("The value of the key `%s` is not a number", key))
+		}
+	}
+
+	result := current + amount
+
+	source, err := json.Marshal(result)
+
+	if err != nil {
+		return 0.0, errors.New(fmt.Sprintf("Unable to encode the value of the key `%s`: %s", key, err))
+	}
+
+	if ttl > 0 {
+		err = s.context.conn.Exec("INSERT OR REPLACE INTO "+storeTable+" (key, value, expires) VALUES (?, ?, ?)", key, string(source), expiry(ttl))
+	} else {
+		err = s.context.conn.Exec("INSERT OR REPLACE INTO "+storeTable+" (key, value, expires) VALUES (?, ?, (SELECT expires FROM "+storeTable+" WHERE key = ?))", key, string(source), key)
+	}
+
+	return result, err
+}
+
+// Delete removes a key from the store, returning false if it didn't exist.
+func (s *Store) Delete(key string) (bool, error) {
+	if err := s.purge(); err != nil {
+		return false, err
+	}
+
+	if err := s.context.conn.Exec("DELETE FROM "+storeTable+" WHERE key = ?", key); err != nil {
+		return false, err
+	}
+
+	return s.context.conn.RowsAffected() > 0, nil
+}
@@ -21,6 +21,13 @@ type CLIConfigType struct {
 	Notification        gotelemetry.Notification
 	WantsFunctionHelp   bool
 	FunctionHelpName    string
+	FunctionHelpFormat  string
+	WantsDriverList     bool
+	IsEvaluating        bool
+	EvalExpression      string
+	EvalFile            string
+	EvalDryRun          bool
+	EvalInteractive     bool
 }
 
 const AgentVersion = "1.2.1"
@@ -64,6 +71,15 @@ func init() {
 
 	functions := app.Command("functions", "Print function help.")
 	functions.Flag("name", "The name of the function whose help should be printed. If not specified, a list of available functions is printed.").StringVar(&CLIConfig.FunctionHelpName)
+	functions.Flag("format", "The output format: `text` prints help to the terminal, while `markdown`, `html`, and `json` export the full reference.").Default("text").EnumVar(&CLIConfig.FunctionHelpFormat, "text", "markdown", "html", "json")
+
+	drivers := app.Command("drivers", "List the SQL drivers available to the `com.telemetryapp.sql` plugin.")
+
+	eval := app.Command("eval", "Evaluate an expression against the data layer and print the result as JSON.")
+	eval.Arg("expression", "The expression to evaluate. If omitted, the expression is read from --file or from stdin.").StringVar(&CLIConfig.EvalExpression)
+	eval.Flag("file", "Read the expression from the given file, or from stdin if the file is -.").Short('f').StringVar(&CLIConfig.EvalFile)
+	eval.Flag("dry", "Roll back any changes made to the data layer after evaluating the expression.").BoolVar(&CLIConfig.EvalDryRun)
+	eval.Flag("repl", "Start an interactive session that evaluates one expression at a time.").Short('i').BoolVar(&CLIConfig.EvalInteractive)
 
 	run := app.Command("run", "Runs the jobs scheduled in the configuration file provided.")
 
@@ -80,6 +96,12 @@ func init() {
 	case functions.FullCommand():
 		CLIConfig.WantsFunctionHelp = true
 
+	case drivers.FullCommand():
+		CLIConfig.WantsDriverList = true
+
+	case eval.FullCommand():
+		CLIConfig.IsEvaluating = true
+
 	case run.FullCommand():
 	default:
 		// Do nothing, runs normally
@@ -9,6 +9,7 @@ import (
 
 type ConfigFile struct {
 	Data        DataConfig
+	Functions   map[string]FunctionConfig
 	AllAccounts []AccountConfig
 }
 
@@ -16,7 +17,7 @@ func NewConfigFile() (*ConfigFile, error) {
 	source, err := ioutil.ReadFile(CLIConfig.ConfigFileLocation)
 
 	if err != nil {
-		if CLIConfig.IsPiping || CLIConfig.IsNotifying {
+		if CLIConfig.IsPiping || CLIConfig.IsNotifying || CLIConfig.IsEvaluating {
 			return &ConfigFile{
 				Data:        DataConfig{},
 				AllAccounts: []AccountConfig{AccountConfig{}},
@@ -32,6 +33,7 @@ func NewConfigFile() (*ConfigFile, error) {
 
 	return &ConfigFile{
 		Data:        result.Data,
+		Functions:   result.Functions,
 		AllAccounts: []AccountConfig{*result},
 	}, err
 }
@@ -43,3 +45,7 @@ func (c *ConfigFile) Accounts() []AccountConfig {
 func (c *ConfigFile) DataConfig() DataConfig {
 	return c.Data
 }
+
+func (c *ConfigFile) FunctionsConfig() map[string]FunctionConfig {
+	return c.Functions
+}
@@ -38,21 +38,55 @@ type Job struct {
 }
 
 type DataConfig struct {
-	DataLocation *string `yaml:"path"`
-	DefaultTTL   *int    `yaml:"ttl"`
+	DataLocation *string           `yaml:"path"`
+	DefaultTTL   *int              `yaml:"ttl"`
+	Retention    []RetentionConfig `yaml:"retention"`
+}
+
+// Struct RetentionConfig describes how long the values of the series whose names match
+// a glob pattern are kept, and at which resolutions.
+type RetentionConfig struct {
+	Series string                `yaml:"series"`
+	Tiers  []RetentionTierConfig `yaml:"tiers"`
+}
+
+// Struct RetentionTierConfig describes a level of detail at which the values of a series
+// are kept. Resolution and Keep are durations like "1m" or "30d"; a tier without a
+// resolution holds the raw values, and a tier without a duration to keep is kept forever.
+type RetentionTierConfig struct {
+	Resolution string `yaml:"resolution"`
+	Keep       string `yaml:"keep"`
+}
+
+// Struct FunctionParameterConfig describes a parameter of a user-defined function.
+// Parameters without a default value are required.
+type FunctionParameterConfig struct {
+	Type        interface{} `yaml:"type"`
+	Description string      `yaml:"description"`
+	Default     interface{} `yaml:"default"`
+}
+
+// Struct FunctionConfig describes a user-defined function, whose body is an expression
+// that reads the value of the parameter called name with {"$var": "name"}.
+type FunctionConfig struct {
+	Description string                             `yaml:"description"`
+	Parameters  map[string]FunctionParameterConfig `yaml:"parameters"`
+	Body        interface{}                        `yaml:"body"`
 }
 
 type AccountConfig struct {
-	APIKey             string     `yaml:"api_key"`
-	APIToken           string     `yaml:"api_token"`
-	Data               DataConfig `yaml:"data"`
-	SubmissionInterval float64    `yaml:"submission_interval"`
-	Jobs               []Job      `yaml:"jobs"`
+	APIKey             string                    `yaml:"api_key"`
+	APIToken           string                    `yaml:"api_token"`
+	Data               DataConfig                `yaml:"data"`
+	Functions          map[string]FunctionConfig `yaml:"functions"`
+	SubmissionInterval float64                   `yaml:"submission_interval"`
+	Jobs               []Job                     `yaml:"jobs"`
 }
 
 type ConfigInterface interface {
 	Accounts() []AccountConfig
 	DataConfig() DataConfig
+	FunctionsConfig() map[string]FunctionConfig
 }
 
 func (a AccountConfig) GetAPIKey() (string, error) {
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// durationComponentRegex matches the first number and unit of a duration. Longer units
+// come first, so that "ms" isn't read as minutes.
+var durationComponentRegex = regexp.MustCompile(`^([0-9]+(?:\.[0-9]*)?|\.[0-9]+)(ns|us|µs|μs|ms|s|m|h|d|w|y)`)
+
+// durationUnits contains the units that time.ParseDuration doesn't know about.
+var durationUnits = map[string]time.Duration{
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+	"y": 365 * 24 * time.Hour,
+}
+
+// ParseDuration works like time.ParseDuration, but also accepts days (d), weeks (w),
+// and years (y) of 365 days, e.g.: "1d12h", "2w", or "1h30d". The units can appear in
+// any order.
+func ParseDuration(source string) (time.Duration, error) {
+	var result time.Duration
+
+	s := strings.TrimSpace(source)
+	negative := strings.HasPrefix(s, "-")
+
+	if negative || strings.HasPrefix(s, "+") {
+		s = s[1:]
+	}
+
+	if s == "" {
+		return 0, errors.New(fmt.Sprintf("Invalid duration `%s`", source))
+	}
+
+	if s == "0" {
+		return 0, nil
+	}
+
+	for s != "" {
+		match := durationComponentRegex.FindStringSubmatch(s)
+
+		if match == nil {
+			return 0, errors.New(fmt.Sprintf("Invalid duration `%s`", source))
+		}
+
+		if unit, ok := durationUnits[match[2]]; ok {
+			count, err := strconv.ParseFloat(match[1], 64)
+
+			if err != nil {
+				return 0, errors.New(fmt.Sprintf("Invalid duration `%s`", source))
+			}
+
+			result += time.Duration(count * float64(unit))
+		} else {
+			d, err := time.ParseDuration(match[0])
+
+			if err != nil {
+				return 0, errors.New(fmt.Sprintf("Invalid duration `%s`", source))
+			}
+
+			result += d
+		}
+
+		s = s[len(match[0]):]
+	}
+
+	if negative {
+		result = -result
+	}
+
+	return result, nil
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected time.Duration
+	}{
+		{"0", 0},
+		{"+0", 0},
+		{"-0", 0},
+		{"90s", 90 * time.Second},
+		{"1.5h", 90 * time.Minute},
+		{".5m", 30 * time.Second},
+		{"1h30m", 90 * time.Minute},
+		{"300ms", 300 * time.Millisecond},
+		{"2us", 2 * time.Microsecond},
+		{"2µs", 2 * time.Microsecond},
+		{"1d", 24 * time.Hour},
+		{"1d12h", 36 * time.Hour},
+		{"12h1d", 36 * time.Hour},
+		{"1h30d", 30*24*time.Hour + time.Hour},
+		{"2w", 14 * 24 * time.Hour},
+		{"1y", 365 * 24 * time.Hour},
+		{"1m1ms", time.Minute + time.Millisecond},
+		{"1ms1m", time.Minute + time.Millisecond},
+		{" 5m ", 5 * time.Minute},
+		{"-1d", -24 * time.Hour},
+		{"+1d", 24 * time.Hour},
+	}
+
+	for _, test := range tests {
+		result, err := ParseDuration(test.source)
+
+		if err != nil {
+			t.Errorf("%q: %s", test.source, err)
+			continue
+		}
+
+		if result != test.expected {
+			t.Errorf("%q: expected %s, got %s", test.source, test.expected, result)
+		}
+	}
+}
+
+func TestParseDurationErrors(t *testing.T) {
+	for _, source := range []string{
+		"",
+		"   ",
+		"-",
+		"+",
+		"+-5m",
+		"--5m",
+		"5",
+		"m",
+		"5x",
+		"1d 12h",
+		"1.2.3h",
+		"h5",
+	} {
+		if result, err := ParseDuration(source); err == nil {
+			t.Errorf("%q: expected an error, got %s", source, result)
+		}
+	}
+}
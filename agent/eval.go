@@ -0,0 +1,240 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/peterh/liner"
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ProcessEvalRequest evaluates a single expression against the data layer and prints
+// its result to stdout as JSON. If dryRun is true, any changes that the expression
+// makes to the data layer are rolled back.
+func ProcessEvalRequest(errorChannel chan error, completionChannel chan bool, source []byte, dryRun bool) {
+	result, err := evaluateExpression(source, dryRun)
+
+	if err != nil {
+		errorChannel <- err
+	} else {
+		fmt.Println(result)
+	}
+
+	completionChannel <- true
+}
+
+// ProcessEvalSession starts an interactive session in which the user can enter
+// expressions and see their results. Each expression is evaluated in its own run, so
+// variables assigned with $let don't carry over from one expression to the next.
+//
+// The session keeps a history of the expressions entered, which is saved to
+// ~/.telemetry_agent_history, and supports a few commands of its own; type :help for
+// a list.
+func ProcessEvalSession(errorChannel chan error, completionChannel chan bool, dryRun bool) {
+	line := liner.NewLiner()
+
+	line.SetCtrlCAborts(true)
+
+	historyPath := ""
+
+	if home := os.Getenv("HOME"); home != "" {
+		historyPath = filepath.Join(home, ".telemetry_agent_history")
+
+		if f, err := os.Open(historyPath); err == nil {
+			line.ReadHistory(f)
+			f.Close()
+		}
+	}
+
+	fmt.Println("Enter an expression to evaluate it, or :help for a list of commands.")
+
+	buffer := ""
+
+	for {
+		prompt := "> "
+
+		if buffer != "" {
+			prompt = "... "
+		}
+
+		input, err := line.Prompt(prompt)
+
+		if err == liner.ErrPromptAborted {
+			buffer = ""
+			continue
+		}
+
+		if err != nil {
+			if err != io.EOF {
+				errorChannel <- err
+			}
+
+			break
+		}
+
+		if buffer == "" && strings.HasPrefix(strings.TrimSpace(input), ":") {
+			line.AppendHistory(input)
+
+			if quit := runSessionCommand(strings.Fields(strings.TrimSpace(input)), &dryRun); quit {
+				break
+			}
+
+			continue
+		}
+
+		buffer += input + "\n"
+
+		if strings.TrimSpace(buffer) == "" {
+			buffer = ""
+			continue
+		}
+
+		// Keep reading until the expression is complete; the decoder reports a value
+		// that was cut short with io.ErrUnexpectedEOF, and any other syntax error is
+		// left for evaluateExpression to report
+		var v interface{}
+
+		if err := json.NewDecoder(strings.NewReader(buffer)).Decode(&v); err == io.ErrUnexpectedEOF {
+			continue
+		}
+
+		line.AppendHistory(strings.TrimSpace(strings.Replace(buffer, "\n", " ", -1)))
+
+		result, err := evaluateExpression([]byte(buffer), dryRun)
+
+		buffer = ""
+
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+		} else {
+			fmt.Println(result)
+		}
+	}
+
+	if historyPath != "" {
+		if f, err := os.Create(historyPath); err == nil {
+			line.WriteHistory(f)
+			f.Close()
+		}
+	}
+
+	line.Close()
+
+	completionChannel <- true
+}
+
+// runSessionCommand executes one of the commands supported by the interactive session,
+// returning true if the session should end.
+func runSessionCommand(command []string, dryRun *bool) bool {
+	switch command[0] {
+	case ":quit", ":q", ":exit":
+		return true
+
+	case ":help":
+		if len(command) > 1 {
+			functions.PrintHelp(command[1])
+			break
+		}
+
+		fmt.Println("  :series          List the series stored in the data layer")
+		fmt.Println("  :functions       List the available functions")
+		fmt.Println("  :help <name>     Show the documentation of a function")
+		fmt.Println("  :dry [on|off]    Show or change whether changes to the data layer are rolled back")
+		fmt.Println("  :quit            End the session")
+
+	case ":functions":
+		functions.PrintHelp("")
+
+	case ":series":
+		context, err := aggregations.GetContext()
+
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			break
+		}
+
+		names, err := aggregations.ListSeries(context)
+
+		context.Close()
+
+		if err != nil {
+			fmt.Printf("Error: %s\n", err)
+			break
+		}
+
+		for _, name := range names {
+			fmt.Println(name)
+		}
+
+	case ":dry":
+		if len(command) > 1 {
+			switch command[1] {
+			case "on":
+				*dryRun = true
+
+			case "off":
+				*dryRun = false
+
+			default:
+				fmt.Println("Usage: :dry [on|off]")
+			}
+		}
+
+		if *dryRun {
+			fmt.Println("Dry run is on; changes to the data layer are rolled back.")
+		} else {
+			fmt.Println("Dry run is off; changes to the data layer are saved.")
+		}
+
+	default:
+		fmt.Printf("Unknown command `%s`. Type :help for a list of commands.\n", command[0])
+	}
+
+	return false
+}
+
+// evaluateExpression runs an expression through functions.Parse in a new transaction
+// and returns its result encoded as JSON. The transaction is rolled back if the
+// evaluation fails or if dryRun is true.
+func evaluateExpression(source []byte, dryRun bool) (string, error) {
+	var expression interface{}
+
+	if err := json.Unmarshal(source, &expression); err != nil {
+		return "", errors.New(fmt.Sprintf("Unable to parse the expression: %s", err))
+	}
+
+	context, err := aggregations.GetContext()
+
+	if err != nil {
+		return "", err
+	}
+
+	defer context.Close()
+
+	if err := context.Begin(); err != nil {
+		return "", err
+	}
+
+	result, err := functions.Parse(context, expression)
+
+	if err != nil || dryRun {
+		context.SetError()
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	output, err := json.MarshalIndent(result, "", "  ")
+
+	if err != nil {
+		return "", errors.New(fmt.Sprintf("Unable to encode the result: %s", err))
+	}
+
+	return string(output), nil
+}
@@ -0,0 +1,22 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"math"
+)
+
+func init() {
+	schemas.LoadSchema("abs")
+	functionHandlers["$abs"] = absHandler
+}
+
+func absHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$abs", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	return math.Abs(data["value"].(float64)), nil
+}
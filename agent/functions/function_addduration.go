@@ -0,0 +1,28 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"time"
+)
+
+func init() {
+	schemas.LoadSchema("addDuration")
+	functionHandlers["$addDuration"] = addDurationHandler
+}
+
+func addDurationHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$addDuration", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	duration, err := durationValue(data["duration"])
+
+	if err != nil {
+		return nil, expressionError("$addDuration", input, "%s", err)
+	}
+
+	return unixTime(time.Unix(int64(data["time"].(float64)), 0).Add(duration)), nil
+}
@@ -0,0 +1,32 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("and")
+	functionHandlers["$and"] = andHandler
+	lazyFunctions["$and"] = true
+}
+
+func andHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$and", input); err != nil {
+		return nil, err
+	}
+
+	for _, expression := range input.([]interface{}) {
+		value, err := evaluateBoolean(context, "$and", input, expression)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !value {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,111 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"time"
+)
+
+func init() {
+	schemas.LoadSchema("anomaly")
+	functionHandlers["$anomaly"] = anomalyHandler
+}
+
+func anomalyHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$anomaly", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	opName := "avg"
+
+	if name, ok := data["op"].(string); ok {
+		opName = name
+	}
+
+	op, err := aggregations.GetFunctionType(opName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	options := aggregations.AnomalyOptions{
+		Count:     1,
+		Threshold: 3.0,
+	}
+
+	if name, ok := data["method"].(string); ok {
+		if options.Method, err = aggregations.GetAnomalyMethod(name); err != nil {
+			return nil, err
+		}
+	}
+
+	if name, ok := data["direction"].(string); ok {
+		if options.Direction, err = aggregations.GetAnomalyDirection(name); err != nil {
+			return nil, err
+		}
+	}
+
+	interval, err := durationValue(data["interval"])
+
+	if err != nil {
+		return nil, expressionError("$anomaly", input, "%s", err)
+	}
+
+	if interval < time.Second {
+		return nil, expressionError("$anomaly", input, "The interval must be at least one second")
+	}
+
+	window, err := durationValue(data["window"])
+
+	if err != nil {
+		return nil, expressionError("$anomaly", input, "%s", err)
+	}
+
+	options.Interval = int(interval / time.Second)
+	options.Baseline = int(window / interval)
+
+	if season, ok := data["season"]; ok {
+		length, err := durationValue(season)
+
+		if err != nil {
+			return nil, expressionError("$anomaly", input, "%s", err)
+		}
+
+		options.SeasonLength = int(length / interval)
+
+		if options.SeasonLength == 0 {
+			return nil, expressionError("$anomaly", input, "The season must be at least as long as the interval")
+		}
+	}
+
+	if threshold, ok := data["threshold"].(float64); ok {
+		options.Threshold = threshold
+	}
+
+	count, hasCount := data["count"].(float64)
+
+	if hasCount {
+		options.Count = int(count)
+	}
+
+	series, err := aggregations.GetSeries(context, data["series"].(string))
+
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := series.DetectAnomalies(op, options)
+
+	if err != nil {
+		return nil, expressionError("$anomaly", input, "%s", err)
+	}
+
+	// Without a count, only the most recent interval is of interest
+	if !hasCount {
+		return result[len(result)-1], nil
+	}
+
+	return interface{}(result), nil
+}
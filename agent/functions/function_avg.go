@@ -0,0 +1,27 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("avg")
+	functionHandlers["$avg"] = avgHandler
+}
+
+func avgHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$avg", input); err != nil {
+		return nil, err
+	}
+
+	values := input.([]interface{})
+
+	result := 0.0
+
+	for _, value := range values {
+		result += value.(float64)
+	}
+
+	return result / float64(len(values)), nil
+}
@@ -0,0 +1,40 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("case")
+	functionHandlers["$case"] = caseHandler
+	lazyFunctions["$case"] = true
+}
+
+func caseHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$case", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	for _, c := range data["cases"].([]interface{}) {
+		c := c.(map[string]interface{})
+
+		condition, err := evaluateBoolean(context, "$case", input, c["when"])
+
+		if err != nil {
+			return nil, err
+		}
+
+		if condition {
+			return Parse(context, c["then"])
+		}
+	}
+
+	if defaultValue, ok := data["default"]; ok {
+		return Parse(context, defaultValue)
+	}
+
+	return nil, nil
+}
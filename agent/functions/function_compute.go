@@ -1,8 +1,6 @@
 package functions
 
 import (
-	"errors"
-	"fmt"
 	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
 	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
 	"time"
@@ -20,30 +18,12 @@ func computeHandler(context *aggregations.Context, input interface{}) (interface
 
 	data := input.(map[string]interface{})
 
-	var op aggregations.FunctionType
+	op, err := aggregations.GetFunctionType(data["op"].(string))
 
-	switch data["op"].(string) {
-	case "sum":
-		op = aggregations.Sum
-
-	case "avg":
-		op = aggregations.Avg
-
-	case "max":
-		op = aggregations.Max
-
-	case "min":
-		op = aggregations.Min
-
-	case "count":
-		op = aggregations.Count
-
-	default:
-		return nil, errors.New(fmt.Sprintf("Unknown operation %s", data["op"].(string)))
+	if err != nil {
+		return nil, err
 	}
 
-	seriesName := data["series"].(string)
-
 	var start, end *time.Time
 
 	if period, ok := data["period"]; ok {
@@ -66,11 +46,13 @@ func computeHandler(context *aggregations.Context, input interface{}) (interface
 		}
 	}
 
-	series, err := aggregations.GetSeries(context, seriesName)
+	series, err := labelledSeries(context, data)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return series.Compute(op, start, end)
+	return groupByLabel(series, data, func(series *aggregations.Series) (interface{}, error) {
+		return series.Compute(op, start, end)
+	})
 }
@@ -0,0 +1,25 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("concat")
+	functionHandlers["$concat"] = concatHandler
+}
+
+func concatHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$concat", input); err != nil {
+		return nil, err
+	}
+
+	result := ""
+
+	for _, value := range input.([]interface{}) {
+		result += stringValue(value)
+	}
+
+	return result, nil
+}
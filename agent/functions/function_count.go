@@ -0,0 +1,19 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("count")
+	functionHandlers["$count"] = countHandler
+}
+
+func countHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$count", input); err != nil {
+		return nil, err
+	}
+
+	return float64(len(input.([]interface{}))), nil
+}
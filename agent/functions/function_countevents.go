@@ -0,0 +1,29 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("countEvents")
+	functionHandlers["$countEvents"] = countEventsHandler
+}
+
+func countEventsHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$countEvents", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	filter, _ := data["where"].(map[string]interface{})
+
+	series, err := aggregations.GetEventSeries(context, data["series"].(string))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return series.Count(int(data["interval"].(float64)), int(data["count"].(float64)), filter)
+}
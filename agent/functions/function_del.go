@@ -0,0 +1,27 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("del")
+	functionHandlers["$del"] = delHandler
+}
+
+func delHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$del", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	store, err := aggregations.GetStore(context)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return store.Delete(data["key"].(string))
+}
@@ -0,0 +1,44 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"time"
+)
+
+func init() {
+	schemas.LoadSchema("delta")
+	functionHandlers["$delta"] = deltaHandler
+}
+
+func deltaHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$delta", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	period, err := durationValue(data["period"])
+
+	if err != nil {
+		return nil, expressionError("$delta", input, "%s", err)
+	}
+
+	counter := true
+
+	if c, ok := data["counter"].(bool); ok {
+		counter = c
+	}
+
+	series, err := aggregations.GetSeries(context, data["series"].(string))
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	change, _, err := series.Change(now.Add(-period), now, counter)
+
+	return change, err
+}
@@ -0,0 +1,33 @@
+package functions
+
+import (
+	"errors"
+	"fmt"
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("div")
+	functionHandlers["$div"] = divHandler
+}
+
+func divHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$div", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	right := data["right"].(float64)
+
+	if right == 0 {
+		if defaultValue, ok := data["default"]; ok {
+			return defaultValue, nil
+		}
+
+		return nil, errors.New(fmt.Sprintf("Division by zero in {$div: %v / %v}, and no default value defined", data["left"], right))
+	}
+
+	return data["left"].(float64) / right, nil
+}
@@ -0,0 +1,43 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("do")
+	functionHandlers["$do"] = doHandler
+}
+
+func doHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$do", input); err != nil {
+		return nil, err
+	}
+
+	// The steps have already been evaluated in order by Parse, since they are the
+	// elements of an array; all that's left to do is pick the result.
+
+	if steps, ok := input.([]interface{}); ok {
+		return steps[len(steps)-1], nil
+	}
+
+	data := input.(map[string]interface{})
+
+	steps := data["steps"].([]interface{})
+	index := len(steps) - 1
+
+	if r, ok := data["return"].(float64); ok {
+		index = int(r)
+
+		if index < 0 {
+			index += len(steps)
+		}
+	}
+
+	if index < 0 || index >= len(steps) {
+		return nil, expressionError("$do", input, "`return` is out of range")
+	}
+
+	return steps[index], nil
+}
@@ -0,0 +1,26 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/config"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("duration")
+	functionHandlers["$duration"] = durationHandler
+}
+
+func durationHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$duration", input); err != nil {
+		return nil, err
+	}
+
+	duration, err := config.ParseDuration(input.(string))
+
+	if err != nil {
+		return nil, expressionError("$duration", input, "%s", err)
+	}
+
+	return duration.Seconds(), nil
+}
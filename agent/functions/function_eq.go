@@ -0,0 +1,21 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("eq")
+	functionHandlers["$eq"] = eqHandler
+}
+
+func eqHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$eq", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	return valuesEqual(data["left"], data["right"]), nil
+}
@@ -0,0 +1,35 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("events")
+	functionHandlers["$events"] = eventsHandler
+}
+
+func eventsHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$events", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	count := 10
+
+	if c, ok := data["count"].(float64); ok {
+		count = int(c)
+	}
+
+	filter, _ := data["where"].(map[string]interface{})
+
+	series, err := aggregations.GetEventSeries(context, data["series"].(string))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return series.Latest(count, filter)
+}
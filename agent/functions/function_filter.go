@@ -0,0 +1,63 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("filter")
+	functionHandlers["$filter"] = filterHandler
+	lazyFunctions["$filter"] = true
+}
+
+func filterHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$filter", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	from, err := Parse(context, data["from"])
+
+	if err != nil {
+		return nil, err
+	}
+
+	elements, ok := from.([]interface{})
+
+	if !ok {
+		return nil, expressionError("$filter", input, "`from` must evaluate to an array, got %#v", from)
+	}
+
+	if context == nil {
+		return nil, expressionError("$filter", input, "Variables are not available in this context")
+	}
+
+	name, ok := data["as"].(string)
+
+	if !ok {
+		name = "item"
+	}
+
+	result := []interface{}{}
+
+	for _, element := range elements {
+		context.PushScope()
+		context.SetVariable(name, element)
+
+		matches, err := evaluateBoolean(context, "$filter", input, copyExpression(data["where"]))
+
+		context.PopScope()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if matches {
+			result = append(result, element)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,29 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("flatten")
+	functionHandlers["$flatten"] = flattenHandler
+}
+
+func flattenHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$flatten", input); err != nil {
+		return nil, err
+	}
+
+	result := []interface{}{}
+
+	for _, element := range input.([]interface{}) {
+		if array, ok := element.([]interface{}); ok {
+			result = append(result, array...)
+		} else {
+			result = append(result, element)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,22 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"math"
+)
+
+func init() {
+	schemas.LoadSchema("floor")
+	functionHandlers["$floor"] = floorHandler
+}
+
+func floorHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$floor", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	return math.Floor(data["value"].(float64)), nil
+}
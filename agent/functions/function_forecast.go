@@ -0,0 +1,104 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"time"
+)
+
+func init() {
+	schemas.LoadSchema("forecast")
+	functionHandlers["$forecast"] = forecastHandler
+}
+
+func forecastHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$forecast", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	opName := "avg"
+
+	if name, ok := data["op"].(string); ok {
+		opName = name
+	}
+
+	op, err := aggregations.GetFunctionType(opName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	options := aggregations.ForecastOptions{
+		Alpha: 0.5,
+		Beta:  0.1,
+		Gamma: 0.1,
+	}
+
+	if name, ok := data["model"].(string); ok {
+		if options.Model, err = aggregations.GetForecastModel(name); err != nil {
+			return nil, err
+		}
+	}
+
+	interval, err := durationValue(data["interval"])
+
+	if err != nil {
+		return nil, expressionError("$forecast", input, "%s", err)
+	}
+
+	if interval < time.Second {
+		return nil, expressionError("$forecast", input, "The interval must be at least one second")
+	}
+
+	window, err := durationValue(data["window"])
+
+	if err != nil {
+		return nil, expressionError("$forecast", input, "%s", err)
+	}
+
+	horizon, err := durationValue(data["horizon"])
+
+	if err != nil {
+		return nil, expressionError("$forecast", input, "%s", err)
+	}
+
+	options.Interval = int(interval / time.Second)
+	options.History = int(window / interval)
+	options.Horizon = int((horizon + interval - 1) / interval)
+
+	if season, ok := data["season"]; ok {
+		length, err := durationValue(season)
+
+		if err != nil {
+			return nil, expressionError("$forecast", input, "%s", err)
+		}
+
+		options.SeasonLength = int(length / interval)
+	}
+
+	if confidence, ok := data["confidence"].(float64); ok {
+		options.Confidence = confidence
+	}
+
+	for key, factor := range map[string]*float64{"alpha": &options.Alpha, "beta": &options.Beta, "gamma": &options.Gamma} {
+		if value, ok := data[key].(float64); ok {
+			*factor = value
+		}
+	}
+
+	series, err := aggregations.GetSeries(context, data["series"].(string))
+
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := series.Forecast(op, options)
+
+	if err != nil {
+		return nil, expressionError("$forecast", input, "%s", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,53 @@
+package functions
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"text/template"
+)
+
+func init() {
+	schemas.LoadSchema("format")
+	functionHandlers["$format"] = formatHandler
+}
+
+func formatHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$format", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	format, hasFormat := data["format"].(string)
+	source, hasTemplate := data["template"].(string)
+
+	switch {
+	case hasFormat && hasTemplate:
+		return nil, expressionError("$format", input, "Use either `format` or `template`, not both")
+
+	case hasFormat:
+		values, _ := data["values"].([]interface{})
+
+		return fmt.Sprintf(format, printfArguments(format, values)...), nil
+
+	case hasTemplate:
+		t, err := template.New("$format").Option("missingkey=error").Parse(source)
+
+		if err != nil {
+			return nil, expressionError("$format", input, "Invalid template: %s", err)
+		}
+
+		var output bytes.Buffer
+
+		if err := t.Execute(&output, data["data"]); err != nil {
+			return nil, expressionError("$format", input, "%s", err)
+		}
+
+		return output.String(), nil
+
+	default:
+		return nil, expressionError("$format", input, "Either `format` or `template` is required")
+	}
+}
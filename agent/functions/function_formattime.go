@@ -0,0 +1,31 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"time"
+)
+
+func init() {
+	schemas.LoadSchema("formatTime")
+	functionHandlers["$formatTime"] = formatTimeHandler
+}
+
+func formatTimeHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$formatTime", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	layout, _ := data["layout"].(string)
+	timezone, _ := data["timezone"].(string)
+
+	location, err := timeLocation(timezone)
+
+	if err != nil {
+		return nil, expressionError("$formatTime", input, "%s", err)
+	}
+
+	return time.Unix(int64(data["time"].(float64)), 0).In(location).Format(timeLayout(layout)), nil
+}
@@ -0,0 +1,37 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("get")
+	functionHandlers["$get"] = getHandler
+}
+
+func getHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$get", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	store, err := aggregations.GetStore(context)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result, ok, err := store.Get(data["key"].(string))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return data["default"], nil
+	}
+
+	return result, nil
+}
@@ -0,0 +1,27 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("gte")
+	functionHandlers["$gte"] = gteHandler
+}
+
+func gteHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$gte", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	result, err := compareValues(data["left"], data["right"])
+
+	if err != nil {
+		return nil, expressionError("$gte", input, "%s", err)
+	}
+
+	return result >= 0, nil
+}
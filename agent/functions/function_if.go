@@ -0,0 +1,36 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("if")
+	functionHandlers["$if"] = ifHandler
+	lazyFunctions["$if"] = true
+}
+
+func ifHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$if", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	condition, err := evaluateBoolean(context, "$if", input, data["condition"])
+
+	if err != nil {
+		return nil, err
+	}
+
+	if condition {
+		return Parse(context, data["then"])
+	}
+
+	if elseValue, ok := data["else"]; ok {
+		return Parse(context, elseValue)
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,50 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"time"
+)
+
+func init() {
+	schemas.LoadSchema("incr")
+	functionHandlers["$incr"] = incrHandler
+}
+
+func incrHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$incr", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	amount := 1.0
+
+	if by, ok := data["by"].(float64); ok {
+		amount = by
+	}
+
+	var ttl time.Duration
+
+	if value, ok := data["ttl"]; ok {
+		var err error
+
+		if ttl, err = durationValue(value); err != nil {
+			return nil, expressionError("$incr", input, "%s", err)
+		}
+	}
+
+	store, err := aggregations.GetStore(context)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := store.Increment(data["key"].(string), amount, ttl)
+
+	if err != nil {
+		return nil, expressionError("$incr", input, "%s", err)
+	}
+
+	return result, nil
+}
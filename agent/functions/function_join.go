@@ -0,0 +1,29 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"strings"
+)
+
+func init() {
+	schemas.LoadSchema("join")
+	functionHandlers["$join"] = joinHandler
+}
+
+func joinHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$join", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	separator, _ := data["separator"].(string)
+	parts := []string{}
+
+	for _, value := range data["values"].([]interface{}) {
+		parts = append(parts, stringValue(value))
+	}
+
+	return strings.Join(parts, separator), nil
+}
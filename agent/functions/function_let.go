@@ -0,0 +1,63 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"sort"
+)
+
+func init() {
+	schemas.LoadSchema("let")
+	functionHandlers["$let"] = letHandler
+	lazyFunctions["$let"] = true
+}
+
+func letHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$let", input); err != nil {
+		return nil, err
+	}
+
+	if context == nil {
+		return nil, expressionError("$let", input, "Variables are not available in this context")
+	}
+
+	data := input.(map[string]interface{})
+
+	vars := data["vars"].(map[string]interface{})
+	values := map[string]interface{}{}
+
+	names := []string{}
+
+	for name := range vars {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		value, err := Parse(context, vars[name])
+
+		if err != nil {
+			return nil, err
+		}
+
+		values[name] = value
+	}
+
+	body, hasBody := data["in"]
+
+	if hasBody {
+		context.PushScope()
+		defer context.PopScope()
+	}
+
+	for name, value := range values {
+		context.SetVariable(name, value)
+	}
+
+	if !hasBody {
+		return nil, nil
+	}
+
+	return Parse(context, body)
+}
@@ -0,0 +1,32 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("limit")
+	functionHandlers["$limit"] = limitHandler
+}
+
+func limitHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$limit", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	from := data["from"].([]interface{})
+	count := int(data["count"].(float64))
+
+	if count < 0 {
+		return from[clampIndex(count, len(from)):], nil
+	}
+
+	if count > len(from) {
+		count = len(from)
+	}
+
+	return from[:count], nil
+}
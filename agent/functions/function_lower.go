@@ -0,0 +1,20 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"strings"
+)
+
+func init() {
+	schemas.LoadSchema("lower")
+	functionHandlers["$lower"] = lowerHandler
+}
+
+func lowerHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$lower", input); err != nil {
+		return nil, err
+	}
+
+	return strings.ToLower(input.(string)), nil
+}
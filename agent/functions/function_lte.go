@@ -0,0 +1,27 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("lte")
+	functionHandlers["$lte"] = lteHandler
+}
+
+func lteHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$lte", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	result, err := compareValues(data["left"], data["right"])
+
+	if err != nil {
+		return nil, expressionError("$lte", input, "%s", err)
+	}
+
+	return result <= 0, nil
+}
@@ -0,0 +1,61 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("map")
+	functionHandlers["$map"] = mapHandler
+	lazyFunctions["$map"] = true
+}
+
+func mapHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$map", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	from, err := Parse(context, data["from"])
+
+	if err != nil {
+		return nil, err
+	}
+
+	elements, ok := from.([]interface{})
+
+	if !ok {
+		return nil, expressionError("$map", input, "`from` must evaluate to an array, got %#v", from)
+	}
+
+	if context == nil {
+		return nil, expressionError("$map", input, "Variables are not available in this context")
+	}
+
+	name, ok := data["as"].(string)
+
+	if !ok {
+		name = "item"
+	}
+
+	result := []interface{}{}
+
+	for _, element := range elements {
+		context.PushScope()
+		context.SetVariable(name, element)
+
+		value, err := Parse(context, copyExpression(data["to"]))
+
+		context.PopScope()
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, value)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,28 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"math"
+)
+
+func init() {
+	schemas.LoadSchema("max")
+	functionHandlers["$max"] = maxHandler
+}
+
+func maxHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$max", input); err != nil {
+		return nil, err
+	}
+
+	values := input.([]interface{})
+
+	result := math.Inf(-1)
+
+	for _, value := range values {
+		result = math.Max(result, value.(float64))
+	}
+
+	return result, nil
+}
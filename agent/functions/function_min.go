@@ -0,0 +1,28 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"math"
+)
+
+func init() {
+	schemas.LoadSchema("min")
+	functionHandlers["$min"] = minHandler
+}
+
+func minHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$min", input); err != nil {
+		return nil, err
+	}
+
+	values := input.([]interface{})
+
+	result := math.Inf(1)
+
+	for _, value := range values {
+		result = math.Min(result, value.(float64))
+	}
+
+	return result, nil
+}
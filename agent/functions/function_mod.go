@@ -0,0 +1,34 @@
+package functions
+
+import (
+	"errors"
+	"fmt"
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"math"
+)
+
+func init() {
+	schemas.LoadSchema("mod")
+	functionHandlers["$mod"] = modHandler
+}
+
+func modHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$mod", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	right := data["right"].(float64)
+
+	if right == 0 {
+		if defaultValue, ok := data["default"]; ok {
+			return defaultValue, nil
+		}
+
+		return nil, errors.New(fmt.Sprintf("Division by zero in {$mod: %v %% %v}, and no default value defined", data["left"], right))
+	}
+
+	return math.Mod(data["left"].(float64), right), nil
+}
@@ -0,0 +1,19 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("not")
+	functionHandlers["$not"] = notHandler
+}
+
+func notHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$not", input); err != nil {
+		return nil, err
+	}
+
+	return !input.(bool), nil
+}
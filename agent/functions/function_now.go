@@ -0,0 +1,20 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"time"
+)
+
+func init() {
+	schemas.LoadSchema("now")
+	functionHandlers["$now"] = nowHandler
+}
+
+func nowHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$now", input); err != nil {
+		return nil, err
+	}
+
+	return unixTime(time.Now()), nil
+}
@@ -0,0 +1,115 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	schemas.LoadSchema("number")
+	functionHandlers["$number"] = numberHandler
+}
+
+func numberHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$number", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	value := data["value"].(float64)
+
+	style := "thousands"
+
+	if s, ok := data["style"].(string); ok {
+		style = s
+	}
+
+	decimals := 0
+
+	switch style {
+	case "currency":
+		decimals = 2
+
+	case "si":
+		decimals = 1
+	}
+
+	if d, ok := data["decimals"].(float64); ok {
+		decimals = int(d)
+	}
+
+	thousandsSeparator := ","
+	decimalSeparator := "."
+	symbol := ""
+
+	if s, ok := data["thousands_separator"].(string); ok {
+		thousandsSeparator = s
+	}
+
+	if s, ok := data["decimal_separator"].(string); ok {
+		decimalSeparator = s
+	}
+
+	if s, ok := data["symbol"].(string); ok {
+		symbol = s
+	} else if style == "currency" {
+		symbol = "$"
+	}
+
+	sign := ""
+
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+
+	suffix := ""
+
+	switch style {
+	case "plain":
+		thousandsSeparator = ""
+
+	case "percent":
+		value *= 100
+		suffix = "%"
+
+	case "si":
+		thousandsSeparator = ""
+
+		for _, prefix := range []string{"k", "M", "G", "T", "P", "E"} {
+			// Check the rounded value so that 999,950 becomes 1.0M rather than 1000.0k
+			if rounded, _ := strconv.ParseFloat(strconv.FormatFloat(value, 'f', decimals, 64), 64); rounded < 1000 {
+				break
+			}
+
+			value /= 1000
+			suffix = prefix
+		}
+	}
+
+	formatted := strconv.FormatFloat(value, 'f', decimals, 64)
+
+	integer := formatted
+	fraction := ""
+
+	if dot := strings.Index(formatted, "."); dot != -1 {
+		integer = formatted[:dot]
+		fraction = decimalSeparator + formatted[dot+1:]
+	}
+
+	if thousandsSeparator != "" {
+		groups := []string{}
+
+		for len(integer) > 3 {
+			groups = append([]string{integer[len(integer)-3:]}, groups...)
+			integer = integer[:len(integer)-3]
+		}
+
+		integer = strings.Join(append([]string{integer}, groups...), thousandsSeparator)
+	}
+
+	return sign + symbol + integer + fraction + suffix, nil
+}
@@ -0,0 +1,32 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("or")
+	functionHandlers["$or"] = orHandler
+	lazyFunctions["$or"] = true
+}
+
+func orHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$or", input); err != nil {
+		return nil, err
+	}
+
+	for _, expression := range input.([]interface{}) {
+		value, err := evaluateBoolean(context, "$or", input, expression)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if value {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
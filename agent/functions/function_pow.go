@@ -0,0 +1,22 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"math"
+)
+
+func init() {
+	schemas.LoadSchema("pow")
+	functionHandlers["$pow"] = powHandler
+}
+
+func powHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$pow", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	return math.Pow(data["base"].(float64), data["exponent"].(float64)), nil
+}
@@ -3,7 +3,6 @@ package functions
 import (
 	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
 	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
-	"time"
 )
 
 func init() {
@@ -18,16 +17,15 @@ func pushHandler(context *aggregations.Context, input interface{}) (interface{},
 
 	data := input.(map[string]interface{})
 
-	seriesName := data["series"].(string)
 	value := data["value"].(float64)
 
-	var ts *time.Time
+	ts, err := whenValue(data["when"])
 
-	if w, ok := data["ts"]; ok {
-		*ts = time.Unix(w.(int64), 0)
+	if err != nil {
+		return nil, expressionError("$push", input, "%s", err)
 	}
 
-	series, err := aggregations.GetSeries(context, seriesName)
+	series, err := labelledSeries(context, data)
 
 	if err != nil {
 		return nil, err
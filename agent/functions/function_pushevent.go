@@ -0,0 +1,44 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"time"
+)
+
+func init() {
+	schemas.LoadSchema("pushEvent")
+	functionHandlers["$pushEvent"] = pushEventHandler
+}
+
+func pushEventHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$pushEvent", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	ts, err := whenValue(data["when"])
+
+	if err != nil {
+		return nil, expressionError("$pushEvent", input, "%s", err)
+	}
+
+	var ttl time.Duration
+
+	if value, ok := data["ttl"]; ok {
+		if ttl, err = durationValue(value); err != nil {
+			return nil, expressionError("$pushEvent", input, "%s", err)
+		}
+	}
+
+	series, err := aggregations.GetEventSeries(context, data["series"].(string))
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = series.Push(ts, data["event"], ttl)
+
+	return nil, err
+}
@@ -0,0 +1,42 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"time"
+)
+
+func init() {
+	schemas.LoadSchema("rate")
+	functionHandlers["$rate"] = rateHandler
+}
+
+func rateHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$rate", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	window, err := durationValue(data["window"])
+
+	if err != nil {
+		return nil, expressionError("$rate", input, "%s", err)
+	}
+
+	counter := true
+
+	if c, ok := data["counter"].(bool); ok {
+		counter = c
+	}
+
+	series, err := aggregations.GetSeries(context, data["series"].(string))
+
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	return series.Rate(now.Add(-window), now, counter)
+}
@@ -0,0 +1,37 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"regexp"
+	"strings"
+)
+
+func init() {
+	schemas.LoadSchema("replace")
+	functionHandlers["$replace"] = replaceHandler
+}
+
+func replaceHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$replace", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	value := data["value"].(string)
+	search := data["search"].(string)
+	replacement := data["replace"].(string)
+
+	if isRegex, ok := data["regex"].(bool); ok && isRegex {
+		r, err := regexp.Compile(search)
+
+		if err != nil {
+			return nil, expressionError("$replace", input, "Invalid regular expression: %s", err)
+		}
+
+		return r.ReplaceAllString(value, replacement), nil
+	}
+
+	return strings.Replace(value, search, replacement, -1), nil
+}
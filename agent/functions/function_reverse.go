@@ -0,0 +1,26 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("reverse")
+	functionHandlers["$reverse"] = reverseHandler
+}
+
+func reverseHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$reverse", input); err != nil {
+		return nil, err
+	}
+
+	from := input.([]interface{})
+	result := []interface{}{}
+
+	for index := len(from) - 1; index >= 0; index-- {
+		result = append(result, from[index])
+	}
+
+	return result, nil
+}
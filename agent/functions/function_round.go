@@ -0,0 +1,37 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"math"
+)
+
+func init() {
+	schemas.LoadSchema("round")
+	functionHandlers["$round"] = roundHandler
+}
+
+func roundHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$round", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	value := data["value"].(float64)
+	precision := 0.0
+
+	if p, ok := data["precision"]; ok {
+		if precision, ok = p.(float64); !ok || precision != math.Trunc(precision) {
+			return nil, expressionError("$round", input, "The precision must be an integer")
+		}
+	}
+
+	multiplier := math.Pow(10, precision)
+
+	if value < 0 {
+		return -math.Floor(-value*multiplier+0.5) / multiplier, nil
+	}
+
+	return math.Floor(value*multiplier+0.5) / multiplier, nil
+}
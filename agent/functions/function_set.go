@@ -0,0 +1,42 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"time"
+)
+
+func init() {
+	schemas.LoadSchema("set")
+	functionHandlers["$set"] = setHandler
+}
+
+func setHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$set", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	var ttl time.Duration
+
+	if value, ok := data["ttl"]; ok {
+		var err error
+
+		if ttl, err = durationValue(value); err != nil {
+			return nil, expressionError("$set", input, "%s", err)
+		}
+	}
+
+	store, err := aggregations.GetStore(context)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := store.Set(data["key"].(string), data["value"], ttl); err != nil {
+		return nil, err
+	}
+
+	return data["value"], nil
+}
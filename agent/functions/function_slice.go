@@ -0,0 +1,56 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("slice")
+	functionHandlers["$slice"] = sliceHandler
+}
+
+func sliceHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$slice", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	from := data["from"].([]interface{})
+	length := len(from)
+
+	start := 0
+	end := length
+
+	if s, ok := data["start"].(float64); ok {
+		start = clampIndex(int(s), length)
+	}
+
+	if e, ok := data["end"].(float64); ok {
+		end = clampIndex(int(e), length)
+	}
+
+	if start >= end {
+		return []interface{}{}, nil
+	}
+
+	return from[start:end], nil
+}
+
+// clampIndex converts a possibly negative index into a position between 0 and length.
+func clampIndex(index, length int) int {
+	if index < 0 {
+		index += length
+	}
+
+	if index < 0 {
+		return 0
+	}
+
+	if index > length {
+		return length
+	}
+
+	return index
+}
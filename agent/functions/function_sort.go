@@ -0,0 +1,92 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"sort"
+)
+
+func init() {
+	schemas.LoadSchema("sort")
+	functionHandlers["$sort"] = sortHandler
+}
+
+func sortHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$sort", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	from := data["from"].([]interface{})
+	key, hasKey := data["by"].(string)
+	descending := data["direction"] == "desc"
+
+	keys := []interface{}{}
+
+	for index, element := range from {
+		if !hasKey {
+			keys = append(keys, element)
+			continue
+		}
+
+		object, ok := element.(map[string]interface{})
+
+		if !ok {
+			return nil, expressionError("$sort", input, "Element %d is not an object", index)
+		}
+
+		value, ok := object[key]
+
+		if !ok {
+			return nil, expressionError("$sort", input, "Element %d does not have a property called `%s`", index, key)
+		}
+
+		keys = append(keys, value)
+	}
+
+	result := make([]interface{}, len(from))
+	copy(result, from)
+
+	s := &sortableValues{values: result, keys: keys, descending: descending}
+
+	sort.Stable(s)
+
+	if s.err != nil {
+		return nil, expressionError("$sort", input, "%s", s.err)
+	}
+
+	return result, nil
+}
+
+// Struct sortableValues sorts an array of values based on a parallel array of keys.
+type sortableValues struct {
+	values     []interface{}
+	keys       []interface{}
+	descending bool
+	err        error
+}
+
+func (s *sortableValues) Len() int {
+	return len(s.values)
+}
+
+func (s *sortableValues) Swap(i, j int) {
+	s.values[i], s.values[j] = s.values[j], s.values[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+
+func (s *sortableValues) Less(i, j int) bool {
+	result, err := compareValues(s.keys[i], s.keys[j])
+
+	if err != nil {
+		s.err = err
+		return false
+	}
+
+	if s.descending {
+		return result > 0
+	}
+
+	return result < 0
+}
@@ -0,0 +1,28 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"strings"
+)
+
+func init() {
+	schemas.LoadSchema("split")
+	functionHandlers["$split"] = splitHandler
+}
+
+func splitHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$split", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	result := []interface{}{}
+
+	for _, part := range strings.Split(data["value"].(string), data["separator"].(string)) {
+		result = append(result, part)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,43 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"time"
+)
+
+func init() {
+	schemas.LoadSchema("startOf")
+	functionHandlers["$startOf"] = startOfHandler
+}
+
+func startOfHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$startOf", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	timezone, _ := data["timezone"].(string)
+	unit := data["unit"].(string)
+
+	location, err := timeLocation(timezone)
+
+	if err != nil {
+		return nil, expressionError("$startOf", input, "%s", err)
+	}
+
+	t := time.Now()
+
+	if ts, ok := data["time"].(float64); ok {
+		t = time.Unix(int64(ts), 0)
+	}
+
+	start, err := startOfPeriod(t.In(location), unit)
+
+	if err != nil {
+		return nil, expressionError("$startOf", input, "%s", err)
+	}
+
+	return unixTime(start), nil
+}
@@ -0,0 +1,40 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("substring")
+	functionHandlers["$substring"] = substringHandler
+}
+
+func substringHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$substring", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	value := []rune(data["value"].(string))
+
+	start := 0
+	end := len(value)
+
+	if s, ok := data["start"].(float64); ok {
+		start = clampIndex(int(s), len(value))
+	}
+
+	if l, ok := data["length"].(float64); ok {
+		if l < 0 {
+			return nil, expressionError("$substring", input, "`length` cannot be negative")
+		}
+
+		if start+int(l) < end {
+			end = start + int(l)
+		}
+	}
+
+	return string(value[start:end]), nil
+}
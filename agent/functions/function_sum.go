@@ -0,0 +1,27 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("sum")
+	functionHandlers["$sum"] = sumHandler
+}
+
+func sumHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$sum", input); err != nil {
+		return nil, err
+	}
+
+	values := input.([]interface{})
+
+	result := 0.0
+
+	for _, value := range values {
+		result += value.(float64)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,46 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("switch")
+	functionHandlers["$switch"] = switchHandler
+	lazyFunctions["$switch"] = true
+}
+
+func switchHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$switch", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	value, err := Parse(context, data["value"])
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range data["cases"].([]interface{}) {
+		c := c.(map[string]interface{})
+
+		when, err := Parse(context, c["when"])
+
+		if err != nil {
+			return nil, err
+		}
+
+		if valuesEqual(value, when) {
+			return Parse(context, c["then"])
+		}
+	}
+
+	if defaultValue, ok := data["default"]; ok {
+		return Parse(context, defaultValue)
+	}
+
+	return nil, nil
+}
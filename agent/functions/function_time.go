@@ -0,0 +1,37 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"time"
+)
+
+func init() {
+	schemas.LoadSchema("time")
+	functionHandlers["$time"] = timeHandler
+}
+
+func timeHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$time", input); err != nil {
+		return nil, err
+	}
+
+	data := input.(map[string]interface{})
+
+	layout, _ := data["layout"].(string)
+	timezone, _ := data["timezone"].(string)
+
+	location, err := timeLocation(timezone)
+
+	if err != nil {
+		return nil, expressionError("$time", input, "%s", err)
+	}
+
+	t, err := time.ParseInLocation(timeLayout(layout), data["value"].(string), location)
+
+	if err != nil {
+		return nil, expressionError("$time", input, "%s", err)
+	}
+
+	return unixTime(t), nil
+}
@@ -0,0 +1,36 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("unique")
+	functionHandlers["$unique"] = uniqueHandler
+}
+
+func uniqueHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$unique", input); err != nil {
+		return nil, err
+	}
+
+	result := []interface{}{}
+
+	for _, element := range input.([]interface{}) {
+		found := false
+
+		for _, existing := range result {
+			if valuesEqual(element, existing) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			result = append(result, element)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,20 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"strings"
+)
+
+func init() {
+	schemas.LoadSchema("upper")
+	functionHandlers["$upper"] = upperHandler
+}
+
+func upperHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$upper", input); err != nil {
+		return nil, err
+	}
+
+	return strings.ToUpper(input.(string)), nil
+}
@@ -0,0 +1,103 @@
+package functions
+
+import (
+	"fmt"
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	schemas.LoadSchema("var")
+	functionHandlers["$var"] = varHandler
+}
+
+func varHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$var", input); err != nil {
+		return nil, err
+	}
+
+	name := input.(string)
+
+	if context == nil {
+		return nil, expressionError("$var", input, "Variables are not available in this context")
+	}
+
+	if value, ok := context.GetVariable(name); ok {
+		return value, nil
+	}
+
+	// A dotted path like `item.name` or `row.0` reads a property of an object or an
+	// element of an array stored in a variable
+	path := strings.Split(name, ".")
+
+	if len(path) > 1 {
+		if value, ok := context.GetVariable(path[0]); ok {
+			return variableProperty(input, value, path[0], path[1:])
+		}
+
+		name = path[0]
+	}
+
+	if names := context.VariableNames(); len(names) > 0 {
+		return nil, &unknownVariableError{input, fmt.Sprintf("Unknown variable `%s`; the variables defined here are: %s", name, strings.Join(names, ", "))}
+	}
+
+	return nil, &unknownVariableError{input, fmt.Sprintf("Unknown variable `%s`; no variables are defined here", name)}
+}
+
+// unknownVariableError is returned by $var when a variable isn't defined. Parse
+// replaces it with an error that names the function call containing the $var
+// expression, which tells the user where the variable was expected to be bound.
+type unknownVariableError struct {
+	input   interface{}
+	message string
+}
+
+func (e *unknownVariableError) Error() string {
+	return expressionError("$var", e.input, "%s", e.message).Error()
+}
+
+// callerError attributes an unknown variable error to the function call that contains
+// the $var expression; any other error is returned unchanged.
+func callerError(name string, payload interface{}, err error) error {
+	if e, ok := err.(*unknownVariableError); ok && name != "$var" {
+		return expressionError(name, payload, "%s", e.message)
+	}
+
+	return err
+}
+
+// variableProperty follows a path of property names and array indices from the value
+// of a variable.
+func variableProperty(input interface{}, value interface{}, name string, path []string) (interface{}, error) {
+	for _, component := range path {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			property, ok := v[component]
+
+			if !ok {
+				return nil, expressionError("$var", input, "`%s` has no property `%s`", name, component)
+			}
+
+			value = property
+
+		case []interface{}:
+			index, err := strconv.Atoi(component)
+
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, expressionError("$var", input, "`%s` has no element `%s`", name, component)
+			}
+
+			value = v[index]
+
+		default:
+			return nil, expressionError("$var", input, "`%s` is neither an object nor an array", name)
+		}
+
+		name += "." + component
+	}
+
+	return value, nil
+}
@@ -0,0 +1,71 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+)
+
+func init() {
+	schemas.LoadSchema("zip")
+	functionHandlers["$zip"] = zipHandler
+}
+
+func zipHandler(context *aggregations.Context, input interface{}) (interface{}, error) {
+	if err := validatePayload("$zip", input); err != nil {
+		return nil, err
+	}
+
+	result := []interface{}{}
+
+	if arrays, ok := input.([]interface{}); ok {
+		if len(arrays) == 0 {
+			return result, nil
+		}
+
+		length := -1
+
+		for _, array := range arrays {
+			if l := len(array.([]interface{})); length == -1 || l < length {
+				length = l
+			}
+		}
+
+		for index := 0; index < length; index++ {
+			row := []interface{}{}
+
+			for _, array := range arrays {
+				row = append(row, array.([]interface{})[index])
+			}
+
+			result = append(result, row)
+		}
+
+		return result, nil
+	}
+
+	arrays := input.(map[string]interface{})
+
+	if len(arrays) == 0 {
+		return result, nil
+	}
+
+	length := -1
+
+	for _, array := range arrays {
+		if l := len(array.([]interface{})); length == -1 || l < length {
+			length = l
+		}
+	}
+
+	for index := 0; index < length; index++ {
+		row := map[string]interface{}{}
+
+		for key, array := range arrays {
+			row[key] = array.([]interface{})[index]
+		}
+
+		result = append(result, row)
+	}
+
+	return result, nil
+}
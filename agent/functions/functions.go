@@ -4,14 +4,104 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/telemetryapp/gotelemetry"
 	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
 	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"regexp"
 	"strings"
 )
 
-type functionHandler func(context *aggregations.Context, input interface{}) (interface{}, error)
+// Type FunctionHandler is the signature of the Go functions that implement the functions
+// of the expression language. A handler receives its input after it has been evaluated
+// and, usually, validates it against the function's schema before using it.
+type FunctionHandler func(context *aggregations.Context, input interface{}) (interface{}, error)
 
-var functionHandlers = map[string]functionHandler{}
+var functionHandlers = map[string]FunctionHandler{}
+
+var functionNameRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var functionErrors = []error{}
+
+// lazyFunctions contains the names of the functions whose arguments are passed to
+// their handlers exactly as they appear in the input, without being evaluated first.
+// This allows functions like $if to evaluate only the arguments they need; their
+// handlers are responsible for calling Parse on those arguments themselves.
+var lazyFunctions = map[string]bool{}
+
+// Register adds a function to the expression language, so that plugins compiled into
+// the agent can provide functions of their own. The name can be given with or without
+// the leading dollar sign, and schema is a JSON Schema (draft 4) that describes the
+// function's input, in the same format as the schemas of the built-in functions:
+//
+//	func init() {
+//		functions.Register("fx_convert", []byte(`{
+//			"title": "$fx_convert",
+//			"group": "Currency",
+//			"description": "Converts an amount between currencies",
+//			"return": {"type": "number", "description": "The converted amount"},
+//			"type": "object",
+//			"properties": {
+//				"amount": {"type": "number", "description": "The amount to convert"},
+//				"from": {"type": "string", "description": "The source currency"},
+//				"to": {"type": "string", "description": "The target currency"}
+//			},
+//			"required": ["amount", "from", "to"]
+//		}`), fxConvertHandler)
+//	}
+//
+// Handlers can call Validate to check their input against the schema. If a function
+// with the same name already exists, or if the schema is invalid, the function is not
+// registered; the error is returned and also recorded, so that it can be retrieved
+// later through GetFunctionRegistrationErrors.
+func Register(name string, schema []byte, handler FunctionHandler) error {
+	name = strings.TrimPrefix(name, "$")
+
+	err := register(name, schema, handler)
+
+	if err != nil {
+		functionErrors = append(functionErrors, err)
+	}
+
+	return err
+}
+
+func register(name string, schema []byte, handler FunctionHandler) error {
+	if !functionNameRegex.MatchString(name) {
+		return gotelemetry.NewError(500, "Invalid function name `"+name+"`")
+	}
+
+	if _, exists := functionHandlers["$"+name]; exists {
+		return gotelemetry.NewError(500, "Duplicate function name `$"+name+"`")
+	}
+
+	if handler == nil {
+		return gotelemetry.NewError(500, "No handler provided for function `$"+name+"`")
+	}
+
+	if err := schemas.AddSchemaJSON("$"+name, schema); err != nil {
+		return gotelemetry.NewError(500, "Invalid schema for function `$"+name+"`: "+err.Error())
+	}
+
+	functionHandlers["$"+name] = handler
+
+	return nil
+}
+
+// GetFunctionRegistrationErrors returns any errors that occurred while functions were
+// being registered with Register.
+func GetFunctionRegistrationErrors() []error {
+	return functionErrors
+}
+
+// Validate checks the input of a function against its schema, returning an error that
+// identifies the expression if the input is invalid.
+func Validate(name string, input interface{}) error {
+	if !strings.HasPrefix(name, "$") {
+		name = "$" + name
+	}
+
+	return validatePayload(name, input)
+}
 
 func validatePayload(name string, payload interface{}) error {
 	if schema, ok := schemas.Schemas[name]; ok {
@@ -34,3 +124,30 @@ func validatePayload(name string, payload interface{}) error {
 		return errors.New("Unable to find a validator for the function `" + name + "`")
 	}
 }
+
+// copyExpression returns a deep copy of an expression. Functions that evaluate the
+// same expression multiple times, like $map, must always work on a copy, because Parse
+// replaces function calls with their results in place.
+func copyExpression(expression interface{}) interface{} {
+	switch e := expression.(type) {
+	case map[string]interface{}:
+		result := map[string]interface{}{}
+
+		for key, v := range e {
+			result[key] = copyExpression(v)
+		}
+
+		return result
+
+	case []interface{}:
+		result := []interface{}{}
+
+		for _, v := range e {
+			result = append(result, copyExpression(v))
+		}
+
+		return result
+	}
+
+	return expression
+}
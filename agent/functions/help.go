@@ -43,6 +43,10 @@ func printFunctionList() {
 
 		fmt.Println()
 	}
+
+	for _, err := range functionErrors {
+		fmt.Printf("Warning: %s\n\n", err)
+	}
 }
 
 func printArgList(schema map[string]interface{}, output io.Writer) {
@@ -128,14 +132,24 @@ func printArgList(schema map[string]interface{}, output io.Writer) {
 }
 
 func printFunctionHelp(name string) {
-	name = strings.ToLower(name)
-
 	if !strings.HasPrefix(name, "$") {
 		name = "$" + name
 	}
 
 	schema, ok := schemas.RawSchemas[name]
 
+	if !ok {
+		// Function names are case-sensitive, but help shouldn't be
+		for index, s := range schemas.RawSchemas {
+			if strings.EqualFold(index, name) {
+				name = index
+				schema = s
+				ok = true
+				break
+			}
+		}
+	}
+
 	if !ok {
 		fmt.Printf("Function `%s` not found.\n\n", name)
 		return
@@ -144,9 +158,15 @@ func printFunctionHelp(name string) {
 	fmt.Printf("Function `%s` - %s\n\n", schema["title"], schema["description"])
 
 	if returnInfo, ok := schema["return"].(map[string]interface{}); ok {
-		fmt.Printf("Returns (%s) %s\n\n", returnInfo["type"], returnInfo["description"])
+		typeName, ok := returnInfo["type"]
+
+		if !ok {
+			typeName = "any"
+		}
+
+		fmt.Printf("Returns (%v) %s\n\n", typeName, returnInfo["description"])
 
-		if returnInfo["type"].(string) == "object" {
+		if typeName == "object" {
 			fmt.Println("\nReturned object properties\n--------------------------\n")
 
 			writer := tablewriter.NewWriter(os.Stdout)
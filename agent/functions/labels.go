@@ -0,0 +1,68 @@
+package functions
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+)
+
+// labelledSeries returns a series restricted to the labels found in the `labels`
+// property of a function's input, if any. The schema makes sure that all the values
+// of the labels are strings.
+func labelledSeries(context *aggregations.Context, data map[string]interface{}) (*aggregations.Series, error) {
+	series, err := aggregations.GetSeries(context, data["series"].(string))
+
+	if err != nil {
+		return nil, err
+	}
+
+	input, ok := data["labels"].(map[string]interface{})
+
+	if !ok || len(input) == 0 {
+		return series, nil
+	}
+
+	labels := map[string]string{}
+
+	for name, value := range input {
+		labels[name] = stringValue(value)
+	}
+
+	return series.WithLabels(labels)
+}
+
+// groupByLabel calls a function once for each value of a label in a series, passing it
+// the series restricted to that value, and returns an array of {label, value} objects
+// sorted by label value. If the `group_by` property of a function's input is empty,
+// it just calls the function with the series.
+func groupByLabel(series *aggregations.Series, data map[string]interface{}, f func(*aggregations.Series) (interface{}, error)) (interface{}, error) {
+	name, ok := data["group_by"].(string)
+
+	if !ok {
+		return f(series)
+	}
+
+	values, err := series.LabelValues(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := []interface{}{}
+
+	for _, value := range values {
+		group, err := series.WithLabels(map[string]string{name: value})
+
+		if err != nil {
+			return nil, err
+		}
+
+		groupResult, err := f(group)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, map[string]interface{}{"label": value, "value": groupResult})
+	}
+
+	return result, nil
+}
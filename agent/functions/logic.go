@@ -0,0 +1,74 @@
+package functions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"reflect"
+)
+
+// expressionError returns an error that identifies the expression in which it occurred,
+// in the same format used by validatePayload.
+func expressionError(name string, payload interface{}, format string, v ...interface{}) error {
+	js, _ := json.Marshal(payload)
+
+	return errors.New(fmt.Sprintf("In expression {%s: %s}: %s", name, string(js), fmt.Sprintf(format, v...)))
+}
+
+// evaluateBoolean evaluates an argument of a lazy function and makes sure that the
+// result is a boolean.
+func evaluateBoolean(context *aggregations.Context, name string, payload interface{}, argument interface{}) (bool, error) {
+	value, err := Parse(context, argument)
+
+	if err != nil {
+		return false, err
+	}
+
+	if result, ok := value.(bool); ok {
+		return result, nil
+	}
+
+	return false, expressionError(name, payload, "Expected a boolean, got %#v", value)
+}
+
+// valuesEqual compares two values decoded from JSON for equality.
+func valuesEqual(left, right interface{}) bool {
+	return reflect.DeepEqual(left, right)
+}
+
+// compareValues compares two numbers or two strings, returning a negative number if
+// left is smaller than right, zero if they are equal, and a positive number otherwise.
+func compareValues(left, right interface{}) (int, error) {
+	switch l := left.(type) {
+	case float64:
+		if r, ok := right.(float64); ok {
+			switch {
+			case l < r:
+				return -1, nil
+
+			case l > r:
+				return 1, nil
+
+			default:
+				return 0, nil
+			}
+		}
+
+	case string:
+		if r, ok := right.(string); ok {
+			switch {
+			case l < r:
+				return -1, nil
+
+			case l > r:
+				return 1, nil
+
+			default:
+				return 0, nil
+			}
+		}
+	}
+
+	return 0, errors.New(fmt.Sprintf("Cannot compare %#v with %#v; both values must be either numbers or strings", left, right))
+}
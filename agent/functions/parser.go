@@ -3,39 +3,78 @@ package functions
 import (
 	"errors"
 	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"sort"
 	"strings"
 )
 
+// sideEffectFunctions contains the names of the functions that change state, either in
+// the data layer or in the variable scope. Parse evaluates them before their siblings.
+var sideEffectFunctions = map[string]bool{
+	"$push":      true,
+	"$pushEvent": true,
+	"$pop":       true,
+	"$let":       true,
+	"$set":       true,
+	"$incr":      true,
+	"$del":       true,
+}
+
+// Parse evaluates an expression, replacing every function call it contains with the
+// function's result.
+//
+// A function call is an object whose only property is the name of the function, like
+// {"$add": {"left": 1, "right": 2}}. An object that mixes a function name with other
+// properties, like {"$add": {...}, "label": "total"}, is rejected with an error, rather
+// than having its other properties silently discarded.
+//
+// Arrays are evaluated in order. The properties of an object are evaluated in two
+// passes: first, the properties whose values contain calls to functions that have side
+// effects, like $push, $pop, and $let; then, all the other properties. Each pass follows
+// the alphabetical order of the keys. This means that, in an object like:
+//
+//	{"count": {"$compute": {"op": "count", "series": "s"}}, "push": {"$push": {"series": "s", "value": 1}}}
+//
+// the value is always pushed before the series is counted. Use $do when you need
+// a different order, or when the side effects happen inside a user-defined function or
+// a function added with Register, which Parse can't detect.
 func Parse(context *aggregations.Context, input interface{}) (interface{}, error) {
 	switch input.(type) {
 	case map[string]interface{}:
 		inputMap := input.(map[string]interface{})
 
-		first := true
-
 		for index, value := range inputMap {
-			if strings.HasPrefix(index, "$") {
-				if first {
-					first = false
-				} else {
-					return nil, errors.New("Function calls must contain a single property.")
+			if !strings.HasPrefix(index, "$") {
+				continue
+			}
+
+			if len(inputMap) > 1 {
+				return nil, errors.New("Function calls must contain a single property.")
+			}
+
+			if handler, ok := functionHandlers[index]; ok {
+				if lazyFunctions[index] {
+					result, err := handler(context, value)
+
+					return result, callerError(index, value, err)
 				}
 
-				if handler, ok := functionHandlers[index]; ok {
-					if value, err := Parse(context, value); err == nil {
-						return handler(context, value)
-					} else {
-						return nil, err
-					}
-				} else {
-					return nil, errors.New("Function " + index + " not found.")
+				evaluated, err := Parse(context, value)
+
+				if err != nil {
+					return nil, callerError(index, value, err)
 				}
+
+				return handler(context, evaluated)
 			} else {
-				if v, err := Parse(context, value); err == nil {
-					inputMap[index] = v
-				} else {
-					return nil, err
-				}
+				return nil, errors.New("Function " + index + " not found.")
+			}
+		}
+
+		for _, index := range evaluationOrder(inputMap) {
+			if v, err := Parse(context, inputMap[index]); err == nil {
+				inputMap[index] = v
+			} else {
+				return nil, err
 			}
 		}
 
@@ -57,3 +96,47 @@ func Parse(context *aggregations.Context, input interface{}) (interface{}, error
 
 	return input, nil
 }
+
+// evaluationOrder returns the keys of an object in the order in which Parse evaluates
+// them.
+func evaluationOrder(input map[string]interface{}) []string {
+	first := []string{}
+	second := []string{}
+
+	for key, value := range input {
+		if hasSideEffects(value) {
+			first = append(first, key)
+		} else {
+			second = append(second, key)
+		}
+	}
+
+	sort.Strings(first)
+	sort.Strings(second)
+
+	return append(first, second...)
+}
+
+// hasSideEffects reports whether an expression contains a call to any of the functions
+// listed in sideEffectFunctions. Only the expression itself is inspected: a call to a
+// user-defined function, or to one added with Register, is not considered to have side
+// effects, even if its body or handler changes state.
+func hasSideEffects(input interface{}) bool {
+	switch v := input.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if sideEffectFunctions[key] || hasSideEffects(value) {
+				return true
+			}
+		}
+
+	case []interface{}:
+		for _, value := range v {
+			if hasSideEffects(value) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,482 @@
+package functions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"html"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Struct referenceArgument describes one of the arguments of a function, as shown
+// in the exported reference. Arguments whose schema uses oneOf or anyOf list each
+// alternative as a separate set of arguments.
+type referenceArgument struct {
+	Name         string
+	Type         string
+	Required     bool
+	Description  string
+	Choice       string
+	Alternatives [][]referenceArgument
+}
+
+// Struct referenceExample is an example from the `examples` array of a schema.
+type referenceExample struct {
+	Description string
+	Expression  string
+	Output      string
+	HasOutput   bool
+}
+
+// Struct referenceFunction collects everything the exported reference says about
+// a function.
+type referenceFunction struct {
+	Name              string
+	Group             string
+	Description       string
+	ReturnType        string
+	ReturnDescription string
+	Arguments         []referenceArgument
+	Examples          []referenceExample
+}
+
+// ExportReference writes the documentation of every function, or of a single function
+// if name is not empty, in the given format, which can be `markdown`, `html`, or
+// `json`. The JSON output contains the raw schemas, keyed by function name, and is
+// suitable for driving editor autocompletion.
+//
+// The examples in each schema are validated against the schema itself; the export fails
+// if any of them is invalid.
+func ExportReference(format string, name string, output io.Writer) error {
+	names := []string{}
+
+	for index := range schemas.RawSchemas {
+		if name == "" || strings.EqualFold(strings.TrimPrefix(index, "$"), strings.TrimPrefix(name, "$")) {
+			names = append(names, index)
+		}
+	}
+
+	if len(names) == 0 {
+		return errors.New(fmt.Sprintf("Function `%s` not found.", name))
+	}
+
+	sort.Strings(names)
+
+	functions := []referenceFunction{}
+
+	for _, index := range names {
+		f, err := newReferenceFunction(index, schemas.RawSchemas[index])
+
+		if err != nil {
+			return err
+		}
+
+		functions = append(functions, f)
+	}
+
+	switch format {
+	case "json":
+		result := map[string]interface{}{}
+
+		for _, index := range names {
+			result[index] = schemas.RawSchemas[index]
+		}
+
+		js, err := json.MarshalIndent(result, "", "  ")
+
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(output, string(js))
+
+		return err
+
+	case "markdown":
+		writeMarkdownReference(functions, output)
+
+	case "html":
+		writeHTMLReference(functions, output)
+
+	default:
+		return errors.New(fmt.Sprintf("Unknown reference format `%s`", format))
+	}
+
+	return nil
+}
+
+func newReferenceFunction(name string, schema map[string]interface{}) (referenceFunction, error) {
+	result := referenceFunction{
+		Name:      name,
+		Arguments: referenceArguments(schema),
+	}
+
+	result.Group, _ = schema["group"].(string)
+	result.Description, _ = schema["description"].(string)
+
+	// Functions whose argument is not an object use the root of their schema to describe
+	// both the function and its argument
+	if len(result.Arguments) == 1 && result.Arguments[0].Name == "" && result.Arguments[0].Description == result.Description {
+		result.Arguments[0].Description = ""
+	}
+
+	if returnInfo, ok := schema["return"].(map[string]interface{}); ok {
+		result.ReturnType = referenceType(returnInfo["type"])
+		result.ReturnDescription, _ = returnInfo["description"].(string)
+	}
+
+	examples, _ := schema["examples"].([]interface{})
+
+	for index, e := range examples {
+		example, ok := e.(map[string]interface{})
+
+		if !ok {
+			return result, errors.New(fmt.Sprintf("Example %d of function `%s` is not an object.", index, name))
+		}
+
+		input, ok := example["input"]
+
+		if !ok {
+			return result, errors.New(fmt.Sprintf("Example %d of function `%s` does not have an input.", index, name))
+		}
+
+		// The arguments of a function are validated after they have been evaluated,
+		// unless the function is lazy, so examples that contain nested function calls
+		// can't be checked against the schema.
+		if lazyFunctions[name] || len(calledFunctions(input)) == 0 {
+			if err := validatePayload(name, input); err != nil {
+				return result, errors.New(fmt.Sprintf("Example %d of function `%s` is invalid: %s", index, name, err))
+			}
+		}
+
+		expression, _ := json.Marshal(map[string]interface{}{name: input})
+
+		r := referenceExample{
+			Expression: string(expression),
+		}
+
+		r.Description, _ = example["description"].(string)
+
+		if out, ok := example["output"]; ok {
+			js, _ := json.Marshal(out)
+
+			r.Output = string(js)
+			r.HasOutput = true
+		}
+
+		result.Examples = append(result.Examples, r)
+	}
+
+	return result, nil
+}
+
+// referenceArguments describes the arguments accepted by a schema. Like printArgList,
+// it treats schemas that don't have properties as a single, unnamed argument.
+func referenceArguments(schema map[string]interface{}) []referenceArgument {
+	properties, ok := schema["properties"].(map[string]interface{})
+
+	if !ok {
+		properties = map[string]interface{}{"": schema}
+	}
+
+	required := map[string]bool{}
+
+	if r, ok := schema["required"].([]interface{}); ok {
+		for _, name := range r {
+			required[fmt.Sprintf("%v", name)] = true
+		}
+	}
+
+	names := []string{}
+
+	for name := range properties {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	result := []referenceArgument{}
+
+	for _, name := range names {
+		data, ok := properties[name].(map[string]interface{})
+
+		if !ok {
+			continue
+		}
+
+		argument := referenceArgument{
+			Name:     name,
+			Type:     referenceType(data["type"]),
+			Required: required[name],
+		}
+
+		argument.Description, _ = data["description"].(string)
+
+		if enum, ok := data["enum"].([]interface{}); ok {
+			values := []string{}
+
+			for _, value := range enum {
+				js, _ := json.Marshal(value)
+				values = append(values, string(js))
+			}
+
+			if argument.Description == "" {
+				argument.Description = "One of: " + strings.Join(values, ", ")
+			} else {
+				argument.Description += ". One of: " + strings.Join(values, ", ")
+			}
+		}
+
+		for _, choice := range []string{"oneOf", "anyOf"} {
+			possibilities, ok := data[choice].([]interface{})
+
+			if !ok {
+				continue
+			}
+
+			if choice == "oneOf" {
+				argument.Choice = "Must be one of:"
+			} else {
+				argument.Choice = "Can be any of:"
+			}
+
+			for _, p := range possibilities {
+				if p, ok := p.(map[string]interface{}); ok {
+					argument.Alternatives = append(argument.Alternatives, referenceArguments(p))
+				}
+			}
+		}
+
+		result = append(result, argument)
+	}
+
+	return result
+}
+
+func referenceType(t interface{}) string {
+	switch v := t.(type) {
+	case nil:
+		return "any"
+
+	case []interface{}:
+		types := []string{}
+
+		for _, element := range v {
+			types = append(types, fmt.Sprintf("%v", element))
+		}
+
+		return strings.Join(types, " or ")
+	}
+
+	return fmt.Sprintf("%v", t)
+}
+
+// referenceGroups splits a list of functions by group, returning the sorted names
+// of the groups along with the functions in each.
+func referenceGroups(functions []referenceFunction) ([]string, map[string][]referenceFunction) {
+	groups := map[string][]referenceFunction{}
+	names := []string{}
+
+	for _, f := range functions {
+		if _, ok := groups[f.Group]; !ok {
+			names = append(names, f.Group)
+		}
+
+		groups[f.Group] = append(groups[f.Group], f)
+	}
+
+	sort.Strings(names)
+
+	return names, groups
+}
+
+// Markdown
+
+func markdownEscape(s string) string {
+	s = strings.Replace(s, "|", "\\|", -1)
+
+	return strings.Replace(s, "\n", " ", -1)
+}
+
+func writeMarkdownArguments(arguments []referenceArgument, depth int, output io.Writer) {
+	indent := strings.Repeat("  ", depth)
+
+	if len(arguments) == 0 {
+		fmt.Fprintf(output, "%sThis function has no parameters.\n\n", indent)
+		return
+	}
+
+	fmt.Fprintf(output, "%s| Name | Type | Required | Description |\n", indent)
+	fmt.Fprintf(output, "%s|------|------|:--------:|-------------|\n", indent)
+
+	for _, a := range arguments {
+		name := "*(value)*"
+
+		if a.Name != "" {
+			name = "`" + a.Name + "`"
+		}
+
+		required := ""
+
+		if a.Required {
+			required = "✓"
+		}
+
+		description := a.Description
+
+		if a.Choice != "" {
+			description = strings.TrimSpace(description + " See the alternatives below.")
+		}
+
+		fmt.Fprintf(output, "%s| %s | %s | %s | %s |\n", indent, name, markdownEscape(a.Type), required, markdownEscape(description))
+	}
+
+	fmt.Fprintln(output)
+
+	for _, a := range arguments {
+		if a.Choice == "" {
+			continue
+		}
+
+		label := "The value"
+
+		if a.Name != "" {
+			label = "`" + a.Name + "`"
+		}
+
+		fmt.Fprintf(output, "%s%s %s\n\n", indent, label, strings.ToLower(a.Choice[:1])+a.Choice[1:])
+
+		for index, alternative := range a.Alternatives {
+			fmt.Fprintf(output, "%s%d. Alternative %d:\n\n", indent, index+1, index+1)
+			writeMarkdownArguments(alternative, depth+1, output)
+		}
+	}
+}
+
+func writeMarkdownReference(functions []referenceFunction, output io.Writer) {
+	fmt.Fprintf(output, "# Function Reference\n\n")
+
+	groupNames, groups := referenceGroups(functions)
+
+	for _, groupName := range groupNames {
+		fmt.Fprintf(output, "## %s\n\n", groupName)
+
+		for _, f := range groups[groupName] {
+			fmt.Fprintf(output, "### `%s`\n\n%s\n\n", f.Name, f.Description)
+
+			if f.ReturnType != "" || f.ReturnDescription != "" {
+				fmt.Fprintf(output, "**Returns** (`%s`): %s\n\n", f.ReturnType, f.ReturnDescription)
+			}
+
+			fmt.Fprintf(output, "#### Arguments\n\n")
+
+			writeMarkdownArguments(f.Arguments, 0, output)
+
+			if len(f.Examples) > 0 {
+				fmt.Fprintf(output, "#### Examples\n\n")
+
+				for _, e := range f.Examples {
+					if e.Description != "" {
+						fmt.Fprintf(output, "%s\n\n", e.Description)
+					}
+
+					fmt.Fprintf(output, "```json\n%s\n```\n\n", e.Expression)
+
+					if e.HasOutput {
+						fmt.Fprintf(output, "Output: `%s`\n\n", e.Output)
+					}
+				}
+			}
+		}
+	}
+}
+
+// HTML
+
+func writeHTMLArguments(arguments []referenceArgument, output io.Writer) {
+	if len(arguments) == 0 {
+		fmt.Fprintln(output, "<p>This function has no parameters.</p>")
+		return
+	}
+
+	fmt.Fprintln(output, "<table>")
+	fmt.Fprintln(output, "<tr><th>Name</th><th>Type</th><th>Required</th><th>Description</th></tr>")
+
+	for _, a := range arguments {
+		name := "<em>(value)</em>"
+
+		if a.Name != "" {
+			name = "<code>" + html.EscapeString(a.Name) + "</code>"
+		}
+
+		required := ""
+
+		if a.Required {
+			required = "✓"
+		}
+
+		fmt.Fprintf(output, "<tr><td>%s</td><td>%s</td><td>%s</td><td>%s", name, html.EscapeString(a.Type), required, html.EscapeString(a.Description))
+
+		if a.Choice != "" {
+			fmt.Fprintf(output, "<p>%s</p>\n<ol>\n", html.EscapeString(a.Choice))
+
+			for _, alternative := range a.Alternatives {
+				fmt.Fprintln(output, "<li>")
+				writeHTMLArguments(alternative, output)
+				fmt.Fprintln(output, "</li>")
+			}
+
+			fmt.Fprintln(output, "</ol>")
+		}
+
+		fmt.Fprintln(output, "</td></tr>")
+	}
+
+	fmt.Fprintln(output, "</table>")
+}
+
+func writeHTMLReference(functions []referenceFunction, output io.Writer) {
+	fmt.Fprintln(output, "<!DOCTYPE html>")
+	fmt.Fprintln(output, "<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Function Reference</title>\n</head>\n<body>")
+	fmt.Fprintln(output, "<h1>Function Reference</h1>")
+
+	groupNames, groups := referenceGroups(functions)
+
+	for _, groupName := range groupNames {
+		fmt.Fprintf(output, "<h2>%s</h2>\n", html.EscapeString(groupName))
+
+		for _, f := range groups[groupName] {
+			fmt.Fprintf(output, "<h3 id=\"%s\"><code>%s</code></h3>\n", html.EscapeString(strings.TrimPrefix(f.Name, "$")), html.EscapeString(f.Name))
+			fmt.Fprintf(output, "<p>%s</p>\n", html.EscapeString(f.Description))
+
+			if f.ReturnType != "" || f.ReturnDescription != "" {
+				fmt.Fprintf(output, "<p><strong>Returns</strong> (<code>%s</code>): %s</p>\n", html.EscapeString(f.ReturnType), html.EscapeString(f.ReturnDescription))
+			}
+
+			fmt.Fprintln(output, "<h4>Arguments</h4>")
+
+			writeHTMLArguments(f.Arguments, output)
+
+			if len(f.Examples) > 0 {
+				fmt.Fprintln(output, "<h4>Examples</h4>")
+
+				for _, e := range f.Examples {
+					if e.Description != "" {
+						fmt.Fprintf(output, "<p>%s</p>\n", html.EscapeString(e.Description))
+					}
+
+					fmt.Fprintf(output, "<pre><code>%s</code></pre>\n", html.EscapeString(e.Expression))
+
+					if e.HasOutput {
+						fmt.Fprintf(output, "<p>Output: <code>%s</code></p>\n", html.EscapeString(e.Output))
+					}
+				}
+			}
+		}
+	}
+
+	fmt.Fprintln(output, "</body>\n</html>")
+}
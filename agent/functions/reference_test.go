@@ -0,0 +1,154 @@
+package functions
+
+import (
+	"encoding/json"
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// dataLayerGroups contains the groups of the functions that need the data layer, whose
+// examples can't be evaluated by the tests.
+var dataLayerGroups = map[string]bool{
+	"Aggregations and Timeseries": true,
+	"Key/Value Store":             true,
+}
+
+func needsDataLayer(expression interface{}) bool {
+	for _, name := range calledFunctions(expression) {
+		if schema, ok := schemas.RawSchemas[name]; ok && dataLayerGroups[schema["group"].(string)] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// canEvaluate reports whether a part of an example can be evaluated on its own: it must
+// not need the data layer, nor read variables that may be bound by the calls around it.
+func canEvaluate(expression interface{}) bool {
+	for _, name := range calledFunctions(expression) {
+		if name == "$var" {
+			return false
+		}
+	}
+
+	return !needsDataLayer(expression)
+}
+
+// normalizeJSON round-trips a value through JSON, so that values of different Go types
+// that have the same encoding compare as equal.
+func normalizeJSON(t *testing.T, value interface{}) interface{} {
+	js, err := json.Marshal(value)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result interface{}
+
+	if err := json.Unmarshal(js, &result); err != nil {
+		t.Fatal(err)
+	}
+
+	return result
+}
+
+// validateExampleCalls checks every function call in an example against the schema of
+// the function. The arguments of a function that isn't lazy are validated after they
+// have been evaluated, as Parse does, unless they can't be evaluated on their own, in
+// which case only the calls nested in them are checked.
+func validateExampleCalls(t *testing.T, example string, expression interface{}) {
+	switch e := expression.(type) {
+	case map[string]interface{}:
+		for key, value := range e {
+			if _, ok := functionHandlers[key]; !ok {
+				if len(key) > 0 && key[0] == '$' {
+					t.Errorf("%s: unknown function `%s`", example, key)
+				}
+
+				validateExampleCalls(t, example, value)
+				continue
+			}
+
+			validateExampleCalls(t, example, value)
+
+			switch {
+			case lazyFunctions[key] || len(calledFunctions(value)) == 0:
+				if err := validatePayload(key, value); err != nil {
+					t.Errorf("%s: %s", example, err)
+				}
+
+			case canEvaluate(value):
+				evaluated, err := Parse(&aggregations.Context{}, copyExpression(value))
+
+				if err != nil {
+					t.Errorf("%s: %s", example, err)
+				} else if err := validatePayload(key, evaluated); err != nil {
+					t.Errorf("%s: %s", example, err)
+				}
+			}
+		}
+
+	case []interface{}:
+		for _, value := range e {
+			validateExampleCalls(t, example, value)
+		}
+	}
+}
+
+func TestReferenceExamples(t *testing.T) {
+	names := []string{}
+
+	for name := range schemas.RawSchemas {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		t.Fatal("No function schemas were loaded")
+	}
+
+	for _, name := range names {
+		if _, err := newReferenceFunction(name, schemas.RawSchemas[name]); err != nil {
+			t.Error(err)
+			continue
+		}
+
+		examples, _ := schemas.RawSchemas[name]["examples"].([]interface{})
+
+		if len(examples) == 0 {
+			t.Errorf("Function `%s` has no examples", name)
+		}
+
+		for index, e := range examples {
+			example := e.(map[string]interface{})
+			expression := map[string]interface{}{name: example["input"]}
+
+			js, _ := json.Marshal(expression)
+			description := string(js)
+
+			validateExampleCalls(t, description, normalizeJSON(t, expression))
+
+			output, hasOutput := example["output"]
+
+			if !hasOutput || needsDataLayer(expression) {
+				continue
+			}
+
+			result, err := Parse(&aggregations.Context{}, normalizeJSON(t, expression))
+
+			if err != nil {
+				t.Errorf("Example %d of function `%s`: %s", index, name, err)
+				continue
+			}
+
+			if expected, actual := normalizeJSON(t, output), normalizeJSON(t, result); !reflect.DeepEqual(expected, actual) {
+				t.Errorf("Example %d of function `%s`: expected %#v, got %#v", index, name, expected, actual)
+			}
+		}
+	}
+}
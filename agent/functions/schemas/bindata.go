@@ -16,6 +16,15 @@ func bindata_read(path, name string) ([]byte, error) {
 }
 
 
+// json_abs_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_abs_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/abs.json",
+		"json/abs.json",
+	)
+}
+
 // json_add_json reads file data from disk.
 // It panics if something went wrong in the process.
 func json_add_json() ([]byte, error) {
@@ -25,6 +34,15 @@ func json_add_json() ([]byte, error) {
 	)
 }
 
+// json_addDuration_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_addDuration_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/addDuration.json",
+		"json/addDuration.json",
+	)
+}
+
 // json_aggregate_json reads file data from disk.
 // It panics if something went wrong in the process.
 func json_aggregate_json() ([]byte, error) {
@@ -34,6 +52,51 @@ func json_aggregate_json() ([]byte, error) {
 	)
 }
 
+// json_and_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_and_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/and.json",
+		"json/and.json",
+	)
+}
+
+// json_anomaly_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_anomaly_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/anomaly.json",
+		"json/anomaly.json",
+	)
+}
+
+// json_avg_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_avg_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/avg.json",
+		"json/avg.json",
+	)
+}
+
+// json_case_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_case_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/case.json",
+		"json/case.json",
+	)
+}
+
+// json_ceil_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_ceil_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/ceil.json",
+		"json/ceil.json",
+	)
+}
+
 // json_compute_json reads file data from disk.
 // It panics if something went wrong in the process.
 func json_compute_json() ([]byte, error) {
@@ -43,6 +106,213 @@ func json_compute_json() ([]byte, error) {
 	)
 }
 
+// json_concat_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_concat_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/concat.json",
+		"json/concat.json",
+	)
+}
+
+// json_count_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_count_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/count.json",
+		"json/count.json",
+	)
+}
+
+// json_countEvents_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_countEvents_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/countEvents.json",
+		"json/countEvents.json",
+	)
+}
+
+// json_del_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_del_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/del.json",
+		"json/del.json",
+	)
+}
+
+// json_delta_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_delta_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/delta.json",
+		"json/delta.json",
+	)
+}
+
+// json_div_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_div_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/div.json",
+		"json/div.json",
+	)
+}
+
+// json_do_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_do_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/do.json",
+		"json/do.json",
+	)
+}
+
+// json_duration_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_duration_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/duration.json",
+		"json/duration.json",
+	)
+}
+
+// json_endOf_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_endOf_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/endOf.json",
+		"json/endOf.json",
+	)
+}
+
+// json_eq_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_eq_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/eq.json",
+		"json/eq.json",
+	)
+}
+
+// json_events_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_events_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/events.json",
+		"json/events.json",
+	)
+}
+
+// json_filter_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_filter_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/filter.json",
+		"json/filter.json",
+	)
+}
+
+// json_flatten_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_flatten_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/flatten.json",
+		"json/flatten.json",
+	)
+}
+
+// json_floor_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_floor_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/floor.json",
+		"json/floor.json",
+	)
+}
+
+// json_forecast_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_forecast_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/forecast.json",
+		"json/forecast.json",
+	)
+}
+
+// json_format_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_format_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/format.json",
+		"json/format.json",
+	)
+}
+
+// json_formatTime_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_formatTime_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/formatTime.json",
+		"json/formatTime.json",
+	)
+}
+
+// json_get_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_get_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/get.json",
+		"json/get.json",
+	)
+}
+
+// json_gt_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_gt_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/gt.json",
+		"json/gt.json",
+	)
+}
+
+// json_gte_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_gte_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/gte.json",
+		"json/gte.json",
+	)
+}
+
+// json_if_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_if_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/if.json",
+		"json/if.json",
+	)
+}
+
+// json_incr_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_incr_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/incr.json",
+		"json/incr.json",
+	)
+}
+
+// json_join_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_join_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/join.json",
+		"json/join.json",
+	)
+}
+
 // json_last_json reads file data from disk.
 // It panics if something went wrong in the process.
 func json_last_json() ([]byte, error) {
@@ -52,6 +322,141 @@ func json_last_json() ([]byte, error) {
 	)
 }
 
+// json_let_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_let_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/let.json",
+		"json/let.json",
+	)
+}
+
+// json_limit_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_limit_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/limit.json",
+		"json/limit.json",
+	)
+}
+
+// json_lower_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_lower_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/lower.json",
+		"json/lower.json",
+	)
+}
+
+// json_lt_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_lt_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/lt.json",
+		"json/lt.json",
+	)
+}
+
+// json_lte_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_lte_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/lte.json",
+		"json/lte.json",
+	)
+}
+
+// json_map_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_map_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/map.json",
+		"json/map.json",
+	)
+}
+
+// json_max_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_max_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/max.json",
+		"json/max.json",
+	)
+}
+
+// json_min_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_min_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/min.json",
+		"json/min.json",
+	)
+}
+
+// json_mod_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_mod_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/mod.json",
+		"json/mod.json",
+	)
+}
+
+// json_mul_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_mul_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/mul.json",
+		"json/mul.json",
+	)
+}
+
+// json_ne_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_ne_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/ne.json",
+		"json/ne.json",
+	)
+}
+
+// json_not_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_not_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/not.json",
+		"json/not.json",
+	)
+}
+
+// json_now_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_now_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/now.json",
+		"json/now.json",
+	)
+}
+
+// json_number_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_number_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/number.json",
+		"json/number.json",
+	)
+}
+
+// json_or_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_or_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/or.json",
+		"json/or.json",
+	)
+}
+
 // json_pick_json reads file data from disk.
 // It panics if something went wrong in the process.
 func json_pick_json() ([]byte, error) {
@@ -70,6 +475,15 @@ func json_pop_json() ([]byte, error) {
 	)
 }
 
+// json_pow_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_pow_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/pow.json",
+		"json/pow.json",
+	)
+}
+
 // json_push_json reads file data from disk.
 // It panics if something went wrong in the process.
 func json_push_json() ([]byte, error) {
@@ -79,6 +493,177 @@ func json_push_json() ([]byte, error) {
 	)
 }
 
+// json_pushEvent_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_pushEvent_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/pushEvent.json",
+		"json/pushEvent.json",
+	)
+}
+
+// json_rate_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_rate_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/rate.json",
+		"json/rate.json",
+	)
+}
+
+// json_replace_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_replace_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/replace.json",
+		"json/replace.json",
+	)
+}
+
+// json_reverse_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_reverse_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/reverse.json",
+		"json/reverse.json",
+	)
+}
+
+// json_round_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_round_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/round.json",
+		"json/round.json",
+	)
+}
+
+// json_set_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_set_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/set.json",
+		"json/set.json",
+	)
+}
+
+// json_slice_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_slice_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/slice.json",
+		"json/slice.json",
+	)
+}
+
+// json_sort_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_sort_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/sort.json",
+		"json/sort.json",
+	)
+}
+
+// json_split_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_split_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/split.json",
+		"json/split.json",
+	)
+}
+
+// json_startOf_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_startOf_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/startOf.json",
+		"json/startOf.json",
+	)
+}
+
+// json_sub_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_sub_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/sub.json",
+		"json/sub.json",
+	)
+}
+
+// json_substring_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_substring_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/substring.json",
+		"json/substring.json",
+	)
+}
+
+// json_sum_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_sum_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/sum.json",
+		"json/sum.json",
+	)
+}
+
+// json_switch_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_switch_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/switch.json",
+		"json/switch.json",
+	)
+}
+
+// json_time_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_time_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/time.json",
+		"json/time.json",
+	)
+}
+
+// json_unique_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_unique_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/unique.json",
+		"json/unique.json",
+	)
+}
+
+// json_upper_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_upper_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/upper.json",
+		"json/upper.json",
+	)
+}
+
+// json_var_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_var_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/var.json",
+		"json/var.json",
+	)
+}
+
+// json_zip_json reads file data from disk.
+// It panics if something went wrong in the process.
+func json_zip_json() ([]byte, error) {
+	return bindata_read(
+		"/Users/marcot/Sites/go/src/github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas/json/zip.json",
+		"json/zip.json",
+	)
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -91,12 +676,77 @@ func Asset(name string) ([]byte, error) {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string] func() ([]byte, error) {
+	"json/abs.json": json_abs_json,
 	"json/add.json": json_add_json,
+	"json/addDuration.json": json_addDuration_json,
 	"json/aggregate.json": json_aggregate_json,
+	"json/and.json": json_and_json,
+	"json/anomaly.json": json_anomaly_json,
+	"json/avg.json": json_avg_json,
+	"json/case.json": json_case_json,
+	"json/ceil.json": json_ceil_json,
 	"json/compute.json": json_compute_json,
+	"json/concat.json": json_concat_json,
+	"json/count.json": json_count_json,
+	"json/countEvents.json": json_countEvents_json,
+	"json/del.json": json_del_json,
+	"json/delta.json": json_delta_json,
+	"json/div.json": json_div_json,
+	"json/do.json": json_do_json,
+	"json/duration.json": json_duration_json,
+	"json/endOf.json": json_endOf_json,
+	"json/eq.json": json_eq_json,
+	"json/events.json": json_events_json,
+	"json/filter.json": json_filter_json,
+	"json/flatten.json": json_flatten_json,
+	"json/floor.json": json_floor_json,
+	"json/forecast.json": json_forecast_json,
+	"json/format.json": json_format_json,
+	"json/formatTime.json": json_formatTime_json,
+	"json/get.json": json_get_json,
+	"json/gt.json": json_gt_json,
+	"json/gte.json": json_gte_json,
+	"json/if.json": json_if_json,
+	"json/incr.json": json_incr_json,
+	"json/join.json": json_join_json,
 	"json/last.json": json_last_json,
+	"json/let.json": json_let_json,
+	"json/limit.json": json_limit_json,
+	"json/lower.json": json_lower_json,
+	"json/lt.json": json_lt_json,
+	"json/lte.json": json_lte_json,
+	"json/map.json": json_map_json,
+	"json/max.json": json_max_json,
+	"json/min.json": json_min_json,
+	"json/mod.json": json_mod_json,
+	"json/mul.json": json_mul_json,
+	"json/ne.json": json_ne_json,
+	"json/not.json": json_not_json,
+	"json/now.json": json_now_json,
+	"json/number.json": json_number_json,
+	"json/or.json": json_or_json,
 	"json/pick.json": json_pick_json,
 	"json/pop.json": json_pop_json,
+	"json/pow.json": json_pow_json,
 	"json/push.json": json_push_json,
+	"json/pushEvent.json": json_pushEvent_json,
+	"json/rate.json": json_rate_json,
+	"json/replace.json": json_replace_json,
+	"json/reverse.json": json_reverse_json,
+	"json/round.json": json_round_json,
+	"json/set.json": json_set_json,
+	"json/slice.json": json_slice_json,
+	"json/sort.json": json_sort_json,
+	"json/split.json": json_split_json,
+	"json/startOf.json": json_startOf_json,
+	"json/sub.json": json_sub_json,
+	"json/substring.json": json_substring_json,
+	"json/sum.json": json_sum_json,
+	"json/switch.json": json_switch_json,
+	"json/time.json": json_time_json,
+	"json/unique.json": json_unique_json,
+	"json/upper.json": json_upper_json,
+	"json/var.json": json_var_json,
+	"json/zip.json": json_zip_json,
 
 }
@@ -2,6 +2,8 @@ package schemas
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/mtabini/gojsonschema"
 )
 
@@ -86,3 +88,95 @@ func LoadSchema(name string) {
 	Schemas["$"+name] = schema
 	RawSchemas["$"+name] = schemaMap
 }
+
+// AddSchemaJSON works like AddSchema, but accepts the schema in JSON format. If the
+// schema doesn't specify a title or a group, it receives the name of the function as its title and is placed in a group called
+// "Other Functions".
+func AddSchemaJSON(name string, source []byte) error {
+	schemaMap := map[string]interface{}{}
+
+	if err := json.Unmarshal(source, &schemaMap); err != nil {
+		return err
+	}
+
+	if _, ok := schemaMap["title"]; !ok {
+		schemaMap["title"] = name
+	}
+
+	if _, ok := schemaMap["group"]; !ok {
+		schemaMap["group"] = "Other Functions"
+	}
+
+	if _, ok := schemaMap["$schema"]; !ok {
+		schemaMap["$schema"] = "http://json-schema.org/draft-04/schema#"
+	}
+
+	return AddSchema(name, schemaMap)
+}
+
+// AddSchema compiles a schema that was not loaded from the bundled assets, such as the
+// one generated for a user-defined function, and registers it under the name of its
+// function, including the leading dollar sign. Unlike LoadSchema, it returns an error
+// instead of panicking if the schema is invalid.
+//
+// The schema must have a non-empty description, and its title and group must be
+// strings. It can only refer to the bundled schemas with $ref; any other reference,
+// including one to a definition inside the schema itself, is rejected.
+func AddSchema(name string, schemaMap map[string]interface{}) error {
+	for _, key := range []string{"title", "group", "description"} {
+		if value, ok := schemaMap[key].(string); !ok || value == "" {
+			return errors.New(fmt.Sprintf("The `%s` property of the schema must be a non-empty string.", key))
+		}
+	}
+
+	if err := checkReferences(schemaMap); err != nil {
+		return err
+	}
+
+	resolveReferencesMap(schemaMap)
+
+	schema, err := gojsonschema.NewJsonSchemaDocument(schemaMap)
+
+	if err != nil {
+		return err
+	}
+
+	Schemas[name] = schema
+	RawSchemas[name] = schemaMap
+
+	return nil
+}
+
+// checkReferences makes sure that every $ref in a schema points to one of the bundled
+// schemas, which resolveReferencesMap can resolve without panicking.
+func checkReferences(schema interface{}) error {
+	switch s := schema.(type) {
+	case map[string]interface{}:
+		if ref, ok := s["$ref"]; ok {
+			name, ok := ref.(string)
+
+			if !ok {
+				return errors.New(fmt.Sprintf("Invalid reference %#v in the schema; references must be strings.", ref))
+			}
+
+			if _, err := Asset(name); err != nil {
+				return errors.New(fmt.Sprintf("The schema refers to `%s`, which is not one of the bundled schemas.", name))
+			}
+		}
+
+		for _, value := range s {
+			if err := checkReferences(value); err != nil {
+				return err
+			}
+		}
+
+	case []interface{}:
+		for _, value := range s {
+			if err := checkReferences(value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package functions
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// stringValue converts a value decoded from JSON into the string used to represent
+// it in text. Numbers are written without trailing zeros, null becomes an empty string,
+// and arrays and objects are encoded as JSON.
+func stringValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+
+	case string:
+		return v
+
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+
+	case bool:
+		return strconv.FormatBool(v)
+
+	default:
+		js, err := json.Marshal(v)
+
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+
+		return string(js)
+	}
+}
+
+// printfArguments prepares a list of values for use with fmt.Sprintf. Since all
+// the numbers in an expression are float64, those that correspond to integer verbs
+// like %d or %x are converted to int64 so that the output matches what users expect.
+func printfArguments(format string, values []interface{}) []interface{} {
+	result := make([]interface{}, len(values))
+	copy(result, values)
+
+	argument := 0
+
+	for index := 0; index < len(format); index++ {
+		if format[index] != '%' {
+			continue
+		}
+
+		// Skip flags, width, and precision until we find the verb
+		for index++; index < len(format) && !isVerb(format[index]); index++ {
+			if format[index] == '*' {
+				argument++
+			}
+		}
+
+		if index >= len(format) || format[index] == '%' {
+			continue
+		}
+
+		if argument < len(result) {
+			if f, ok := result[argument].(float64); ok && isIntegerVerb(format[index]) {
+				result[argument] = int64(f)
+			}
+		}
+
+		argument++
+	}
+
+	return result
+}
+
+func isVerb(c byte) bool {
+	return c == '%' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIntegerVerb(c byte) bool {
+	switch c {
+	case 'd', 'b', 'o', 'O', 'x', 'X', 'c', 'U':
+		return true
+	}
+
+	return false
+}
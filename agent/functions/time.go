@@ -0,0 +1,145 @@
+package functions
+
+import (
+	"errors"
+	"fmt"
+	"github.com/telemetryapp/gotelemetry_agent/agent/config"
+	"strings"
+	"time"
+)
+
+// timeLayouts maps the names of commonly-used layouts to their Go equivalents, so
+// that users don't need to remember Go's reference time for the most common formats.
+var timeLayouts = map[string]string{
+	"rfc3339":  time.RFC3339,
+	"rfc1123":  time.RFC1123,
+	"rfc1123z": time.RFC1123Z,
+	"rfc822":   time.RFC822,
+	"rfc822z":  time.RFC822Z,
+	"ansic":    time.ANSIC,
+	"kitchen":  time.Kitchen,
+	"date":     "2006-01-02",
+	"datetime": "2006-01-02 15:04:05",
+}
+
+// timeLayout returns the Go layout that corresponds to a name from timeLayouts, or the
+// layout itself if it isn't a known name. An empty string selects RFC 3339.
+func timeLayout(layout string) string {
+	if layout == "" {
+		return time.RFC3339
+	}
+
+	if result, ok := timeLayouts[strings.ToLower(layout)]; ok {
+		return result
+	}
+
+	return layout
+}
+
+// timeLocation loads a time zone from the IANA database. An empty name selects the
+// agent's local time zone.
+func timeLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+
+	location, err := time.LoadLocation(name)
+
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Unknown time zone `%s`", name))
+	}
+
+	return location, nil
+}
+
+// durationValue converts an argument that is either a number of seconds or a string
+// accepted by config.ParseDuration into a duration.
+func durationValue(value interface{}) (time.Duration, error) {
+	switch v := value.(type) {
+	case float64:
+		return time.Duration(v * float64(time.Second)), nil
+
+	case string:
+		return config.ParseDuration(v)
+	}
+
+	return 0, errors.New(fmt.Sprintf("Expected a duration, got %#v", value))
+}
+
+// whenValue converts the `when` property of functions that record data, which can be
+// either a UNIX timestamp or an RFC 3339 string, into a time. It returns nil if the
+// property is missing, so that the current time is used.
+func whenValue(value interface{}) (*time.Time, error) {
+	switch v := value.(type) {
+	case float64:
+		t := time.Unix(int64(v), 0)
+
+		return &t, nil
+
+	case string:
+		t, err := time.Parse(time.RFC3339, v)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &t, nil
+	}
+
+	return nil, nil
+}
+
+// unixTime converts a time into the UNIX timestamp format used by expressions.
+func unixTime(t time.Time) float64 {
+	return float64(t.Unix())
+}
+
+// startOfPeriod returns the beginning of the hour, day, week, month, or year that contains
+// a given time, in the time's location. Weeks start on Monday.
+func startOfPeriod(t time.Time, unit string) (time.Time, error) {
+	switch unit {
+	case "minute":
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, t.Location()), nil
+
+	case "hour":
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location()), nil
+
+	case "day":
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()), nil
+
+	case "week":
+		offset := (int(t.Weekday()) + 6) % 7
+
+		return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location()), nil
+
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()), nil
+
+	case "year":
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location()), nil
+	}
+
+	return t, errors.New(fmt.Sprintf("Unknown time unit `%s`", unit))
+}
+
+// nextPeriod returns the beginning of the period that follows the one that starts at t.
+func nextPeriod(start time.Time, unit string) time.Time {
+	switch unit {
+	case "minute":
+		return start.Add(time.Minute)
+
+	case "hour":
+		return start.Add(time.Hour)
+
+	case "day":
+		return start.AddDate(0, 0, 1)
+
+	case "week":
+		return start.AddDate(0, 0, 7)
+
+	case "month":
+		return start.AddDate(0, 1, 0)
+	}
+
+	return start.AddDate(1, 0, 0)
+}
@@ -0,0 +1,275 @@
+package functions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/config"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions/schemas"
+	"sort"
+	"strings"
+)
+
+// RegisterUserFunctions registers the functions declared in the `functions` section
+// of the configuration file. For example:
+//
+//	functions:
+//	  percentOfTarget:
+//	    description: The 24-hour moving average of a series as a percentage of a target
+//	    parameters:
+//	      series:
+//	        type: string
+//	        description: The name of the series
+//	      target:
+//	        type: number
+//	        description: The target value
+//	        default: 100
+//	    body:
+//	      $mul:
+//	        left: 100
+//	        right:
+//	          $div:
+//	            left: { $compute: { op: avg, series: { $var: series }, period: 86400 } }
+//	            right: { $var: target }
+//
+// defines a function that can be called as {"$percentOfTarget": {"series": "sales"}}.
+//
+// Each function receives a schema generated from its parameters, so that its input is
+// validated and it appears in the output of the `functions` command like a built-in
+// function. The body is evaluated in a new variable scope in which every parameter is
+// bound to its value, so it reads parameters with $var; parameters without a default
+// are required. The values of the parameters are never evaluated as expressions
+// themselves.
+//
+// A function can call built-in functions as well as other user-defined functions,
+// but not itself, either directly or indirectly.
+func RegisterUserFunctions(cfg config.ConfigInterface) error {
+	declarations := cfg.FunctionsConfig()
+
+	if len(declarations) == 0 {
+		return nil
+	}
+
+	names := []string{}
+
+	for name := range declarations {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	bodies := map[string]interface{}{}
+
+	for _, name := range names {
+		if !functionNameRegex.MatchString(name) {
+			return errors.New(fmt.Sprintf("Invalid name `%s` for user-defined function; names must be made of letters, digits, and underscores, and cannot start with a digit.", name))
+		}
+
+		if _, ok := functionHandlers["$"+name]; ok {
+			return errors.New(fmt.Sprintf("The user-defined function `$%s` has the same name as a built-in function.", name))
+		}
+
+		body, err := jsonValueFromYaml(declarations[name].Body)
+
+		if err != nil {
+			return errors.New(fmt.Sprintf("Unable to parse the body of the user-defined function `$%s`: %s", name, err))
+		}
+
+		if body == nil {
+			return errors.New(fmt.Sprintf("The user-defined function `$%s` does not have a body.", name))
+		}
+
+		bodies["$"+name] = body
+	}
+
+	for _, name := range names {
+		if err := checkUserFunctionCalls("$"+name, bodies, []string{}); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range names {
+		if err := registerUserFunction("$"+name, declarations[name], bodies["$"+name]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkUserFunctionCalls makes sure that every function called by a user-defined
+// function exists, and that the function doesn't end up calling itself.
+func checkUserFunctionCalls(name string, bodies map[string]interface{}, path []string) error {
+	for _, caller := range path {
+		if caller == name {
+			return errors.New(fmt.Sprintf("The user-defined function `%s` is recursive: %s -> %s", path[0], strings.Join(path, " -> "), name))
+		}
+	}
+
+	path = append(path, name)
+
+	for _, callee := range calledFunctions(bodies[name]) {
+		if _, ok := bodies[callee]; ok {
+			if err := checkUserFunctionCalls(callee, bodies, path); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if _, ok := functionHandlers[callee]; !ok {
+			return errors.New(fmt.Sprintf("The user-defined function `%s` calls `%s`, which does not exist.", name, callee))
+		}
+	}
+
+	return nil
+}
+
+// calledFunctions returns the sorted names of the functions called by an expression.
+func calledFunctions(expression interface{}) []string {
+	found := map[string]bool{}
+
+	var walk func(interface{})
+
+	walk = func(e interface{}) {
+		switch v := e.(type) {
+		case map[string]interface{}:
+			for key, value := range v {
+				if strings.HasPrefix(key, "$") {
+					found[key] = true
+				}
+
+				walk(value)
+			}
+
+		case []interface{}:
+			for _, value := range v {
+				walk(value)
+			}
+		}
+	}
+
+	walk(expression)
+
+	result := []string{}
+
+	for name := range found {
+		result = append(result, name)
+	}
+
+	sort.Strings(result)
+
+	return result
+}
+
+func registerUserFunction(name string, declaration config.FunctionConfig, body interface{}) error {
+	properties := map[string]interface{}{}
+	required := []interface{}{}
+	defaults := map[string]interface{}{}
+
+	for parameter, p := range declaration.Parameters {
+		property := map[string]interface{}{
+			"description": p.Description,
+		}
+
+		if p.Type != nil {
+			t, err := jsonValueFromYaml(p.Type)
+
+			if err != nil {
+				return err
+			}
+
+			property["type"] = t
+		}
+
+		if p.Default == nil {
+			required = append(required, parameter)
+		} else {
+			value, err := jsonValueFromYaml(p.Default)
+
+			if err != nil {
+				return err
+			}
+
+			defaults[parameter] = value
+
+			js, _ := json.Marshal(value)
+
+			if p.Description == "" {
+				property["description"] = fmt.Sprintf("Defaults to %s", js)
+			} else {
+				property["description"] = fmt.Sprintf("%s. Defaults to %s", strings.TrimSuffix(p.Description, "."), js)
+			}
+		}
+
+		properties[parameter] = property
+	}
+
+	description := declaration.Description
+
+	if description == "" {
+		description = "User-defined function"
+	}
+
+	schema := map[string]interface{}{
+		"id":                   "/" + strings.TrimPrefix(name, "$"),
+		"$schema":              "http://json-schema.org/draft-04/schema#",
+		"title":                name,
+		"group":                "User-Defined Functions",
+		"description":          description,
+		"return":               map[string]interface{}{"description": "The result of the function's body"},
+		"type":                 "object",
+		"additionalProperties": false,
+		"properties":           properties,
+	}
+
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	if err := schemas.AddSchema(name, schema); err != nil {
+		return errors.New(fmt.Sprintf("Unable to generate a schema for the user-defined function `%s`: %s", name, err))
+	}
+
+	functionHandlers[name] = func(context *aggregations.Context, input interface{}) (interface{}, error) {
+		if err := validatePayload(name, input); err != nil {
+			return nil, err
+		}
+
+		if context == nil {
+			return nil, expressionError(name, input, "Variables are not available in this context")
+		}
+
+		context.PushScope()
+		defer context.PopScope()
+
+		for parameter, value := range defaults {
+			context.SetVariable(parameter, value)
+		}
+
+		for parameter, value := range input.(map[string]interface{}) {
+			context.SetVariable(parameter, value)
+		}
+
+		return Parse(context, copyExpression(body))
+	}
+
+	return nil
+}
+
+// jsonValueFromYaml converts a value decoded from YAML into the representation that
+// encoding/json would have produced, so that, for example, all numbers are float64.
+func jsonValueFromYaml(value interface{}) (interface{}, error) {
+	source, err := json.Marshal(config.MapFromYaml(value))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result interface{}
+
+	err = json.Unmarshal(source, &result)
+
+	return result, err
+}
@@ -0,0 +1,865 @@
+package plugin
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/evanphx/json-patch"
+	"github.com/telemetryapp/gotelemetry"
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/config"
+	"github.com/telemetryapp/gotelemetry_agent/agent/job"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// init() registers this plugin with the Plugin Manager.
+func init() {
+	job.RegisterPlugin("com.telemetryapp.csv", CSVPluginFactory)
+}
+
+// Func CSVPluginFactory generates a blank plugin instance of the
+// `com.telemetryapp.csv` plugin
+func CSVPluginFactory() job.PluginInstance {
+	return &CSVPlugin{
+		PluginHelper: job.NewPluginHelper(),
+	}
+}
+
+// Struct CSVPlugin allows you to extract data from a CSV or TSV file and use it to
+// populate a Telemetry flow, or to append it to one or more series in the data layer.
+//
+// For configuration parameters, see Init()
+type CSVPlugin struct {
+	*job.PluginHelper
+	filePath   string
+	delimiter  rune
+	header     bool
+	columns    []string
+	filters    []csvFilter
+	last       int
+	timeFormat string
+	series     []csvSeries
+	patch      string
+	flowTag    string
+	variant    string
+	flow       *gotelemetry.Flow
+}
+
+// Struct csvFilter describes a condition that a row must satisfy in order to be
+// included in the output.
+type csvFilter struct {
+	column string
+	op     string
+	value  interface{}
+	regex  *regexp.Regexp
+}
+
+// Struct csvSeries describes how the rows of a file are appended to a series.
+type csvSeries struct {
+	name      string
+	value     string
+	timestamp string
+}
+
+// Init initializes the plugin.
+//
+// The required configuration parameters are:
+//
+// - path                         The path to the CSV file
+//
+// - observe                      Whether the plugin should observe the file for changes, and run whenever changes are detected
+//
+// - refresh                      The number of seconds between subsequent executions of the plugin. Ignored if `observe` is true
+//
+//   - delimiter                    The field delimiter; use `tab` or "\t" for TSV files. Default: a comma, or a tab if the file's
+//     extension is `.tsv`
+//
+//   - header                       Whether the first row of the file contains column names. If true, each row is returned as an
+//     object keyed by column name; otherwise, it is returned as an array. Default: false
+//
+//   - columns                      An optional list of the columns to extract. Columns can be identified by name (if `header` is
+//     true) or by zero-based index. Default: all columns
+//
+//   - filters                      An optional list of conditions that rows must satisfy, each with a `column`, an `op` (one of `eq`,
+//     `ne`, `gt`, `gte`, `lt`, `lte`, `contains`, or `matches`), and a `value`
+//
+//   - rows                         Which of the matching rows to extract: `all`, `latest` (the last row only, returned on its
+//     own rather than inside an array), or a number N to extract the last N rows. Default: all
+//
+// - flow_tag                     The tag of the flow to populate. Optional if `series` is provided
+//
+// - variant                      The varient of the flow
+//
+// - template                     A template that will be used to populate the flow when it is created
+//
+// - patch                        A JSON Patch payload that describes how the data extracted from the file must be applied to the flow
+//
+//   - series                       An optional list of series to which rows are appended, each with a `name`, a `value` column,
+//     and an optional `timestamp` column
+//
+//   - time_format                  The Go layout of the values in the timestamp columns. Default: UNIX timestamps, RFC 3339,
+//     `2006-01-02 15:04:05`, or `2006-01-02`, whichever matches
+//
+// Values are typed automatically: numbers become numbers, `true` and `false` become
+// booleans, empty fields become null, and everything else is a string.
+//
+// As with the Excel plugin, the patch is executed once per update. You can use "$$#" as
+// a placeholder for all the extracted rows (or for the only row, with `rows: latest`),
+// and "$$n" as a placeholder for row n. A string that contains nothing but a
+// placeholder is replaced by the row itself; a placeholder inside a longer string is
+// replaced by the row's JSON text. The values read from the file are never scanned for
+// placeholders.
+//
+// When a series has a `timestamp` column, only the rows that are newer than the latest
+// value in the series are appended, so that reading the same file over and over does
+// not result in duplicate data. Without a `timestamp` column, the extracted rows are
+// appended with the current time every time the plugin runs; this is mostly useful in
+// conjunction with `rows: latest`.
+//
+// For example:
+//
+//	jobs:
+//	  - id: Daily sales
+//	    plugin: com.telemetryapp.csv
+//	    config:
+//	      path: /exports/sales.csv
+//	      observe: true
+//	      header: true
+//	      columns: [region, total]
+//	      filters:
+//	        - { column: total, op: gt, value: 0 }
+//	      rows: 10
+//	      flow_tag: sales_by_region
+//	      variant: leaderboard
+//	      patch:
+//	        - { "op": "replace", "path": "/values", "value": "$$#" }
+//	      series:
+//	        - { name: sales, value: total, timestamp: date }
+func (p *CSVPlugin) Init(job *job.Job) error {
+	var ok bool
+
+	c := job.Config()
+
+	p.filePath, ok = c["path"].(string)
+
+	if !ok {
+		return errors.New("The required `path` property (`string`) is either missing or of the wrong type.")
+	}
+
+	if err := p.parseDelimiter(c["delimiter"]); err != nil {
+		return err
+	}
+
+	if header, ok := c["header"]; ok {
+		if p.header, ok = header.(bool); !ok {
+			return errors.New("The `header` property must be a boolean.")
+		}
+	}
+
+	if columns, ok := c["columns"].([]interface{}); ok {
+		for _, column := range columns {
+			p.columns = append(p.columns, fmt.Sprintf("%v", column))
+		}
+	} else if _, ok := c["columns"]; ok {
+		return errors.New("The `columns` property must be an array.")
+	}
+
+	if err := p.parseFilters(c["filters"]); err != nil {
+		return err
+	}
+
+	switch rows := c["rows"].(type) {
+	case nil:
+		p.last = 0
+
+	case int:
+		if rows < 1 {
+			return errors.New("The `rows` property must be `all`, `latest`, or a positive number.")
+		}
+
+		p.last = rows
+
+	case string:
+		switch rows {
+		case "all":
+			p.last = 0
+
+		case "latest":
+			p.last = -1
+
+		default:
+			return errors.New("The `rows` property must be `all`, `latest`, or a positive number.")
+		}
+
+	default:
+		return errors.New("The `rows` property must be `all`, `latest`, or a positive number.")
+	}
+
+	if timeFormat, ok := c["time_format"].(string); ok {
+		p.timeFormat = timeFormat
+	}
+
+	if err := p.parseSeries(c["series"]); err != nil {
+		return err
+	}
+
+	if flowTag, ok := c["flow_tag"].(string); ok {
+		p.flowTag = flowTag
+
+		p.variant, ok = c["variant"].(string)
+
+		if !ok {
+			return errors.New("The required `variant` property (`string`) is either missing or of the wrong type.")
+		}
+
+		patch, err := json.Marshal(config.MapFromYaml(c["patch"]))
+
+		if err != nil {
+			job.ReportError(err)
+			return err
+		}
+
+		p.patch = string(patch)
+
+		p.flow, err = job.GetOrCreateFlow(p.flowTag, p.variant, c["template"])
+
+		if err != nil {
+			return err
+		}
+	} else if len(p.series) == 0 {
+		return errors.New("Either a `flow_tag` or a `series` property must be provided.")
+	}
+
+	if observe, ok := c["observe"].(bool); ok && observe {
+		p.PluginHelper.AddTaskWithFileObservation(p.performAllTasks, p.filePath)
+	} else if refresh, ok := c["refresh"].(int); ok {
+		p.PluginHelper.AddTaskWithClosure(p.performAllTasks, time.Duration(refresh)*time.Second)
+	} else {
+		p.PluginHelper.AddTaskWithClosure(p.performAllTasks, 0)
+	}
+
+	return nil
+}
+
+func (p *CSVPlugin) parseDelimiter(delimiter interface{}) error {
+	switch delimiter := delimiter.(type) {
+	case nil:
+		if strings.ToLower(filepath.Ext(p.filePath)) == ".tsv" {
+			p.delimiter = '\t'
+		} else {
+			p.delimiter = ','
+		}
+
+	case string:
+		if delimiter == "tab" || delimiter == `\t` {
+			delimiter = "\t"
+		}
+
+		runes := []rune(delimiter)
+
+		if len(runes) != 1 {
+			return errors.New("The `delimiter` property must be a single character.")
+		}
+
+		p.delimiter = runes[0]
+
+	default:
+		return errors.New("The `delimiter` property must be a string.")
+	}
+
+	return nil
+}
+
+func (p *CSVPlugin) parseFilters(filters interface{}) error {
+	if filters == nil {
+		return nil
+	}
+
+	filterList, ok := config.MapFromYaml(filters).([]interface{})
+
+	if !ok {
+		return errors.New("The `filters` property must be an array.")
+	}
+
+	for index, f := range filterList {
+		f, ok := f.(map[string]interface{})
+
+		if !ok {
+			return errors.New(fmt.Sprintf("Filter %d must be an object.", index))
+		}
+
+		filter := csvFilter{}
+
+		if column, ok := f["column"]; ok {
+			filter.column = fmt.Sprintf("%v", column)
+		} else {
+			return errors.New(fmt.Sprintf("Filter %d is missing the required `column` property.", index))
+		}
+
+		filter.op, ok = f["op"].(string)
+
+		if !ok {
+			filter.op = "eq"
+		}
+
+		filter.value = csvNormalizeValue(f["value"])
+
+		switch filter.op {
+		case "eq", "ne", "gt", "gte", "lt", "lte", "contains":
+			// Nothing to prepare
+
+		case "matches":
+			rx, err := regexp.Compile(fmt.Sprintf("%v", filter.value))
+
+			if err != nil {
+				return errors.New(fmt.Sprintf("Filter %d has an invalid regular expression: %s", index, err))
+			}
+
+			filter.regex = rx
+
+		default:
+			return errors.New(fmt.Sprintf("Filter %d has an unknown operation `%s`.", index, filter.op))
+		}
+
+		p.filters = append(p.filters, filter)
+	}
+
+	return nil
+}
+
+func (p *CSVPlugin) parseSeries(series interface{}) error {
+	if series == nil {
+		return nil
+	}
+
+	seriesList, ok := config.MapFromYaml(series).([]interface{})
+
+	if !ok {
+		return errors.New("The `series` property must be an array.")
+	}
+
+	for index, s := range seriesList {
+		s, ok := s.(map[string]interface{})
+
+		if !ok {
+			return errors.New(fmt.Sprintf("Series %d must be an object.", index))
+		}
+
+		result := csvSeries{}
+
+		result.name, ok = s["name"].(string)
+
+		if !ok {
+			return errors.New(fmt.Sprintf("Series %d is missing the required `name` property.", index))
+		}
+
+		if value, ok := s["value"]; ok {
+			result.value = fmt.Sprintf("%v", value)
+		} else {
+			return errors.New(fmt.Sprintf("Series %d is missing the required `value` property.", index))
+		}
+
+		if timestamp, ok := s["timestamp"]; ok {
+			result.timestamp = fmt.Sprintf("%v", timestamp)
+		}
+
+		p.series = append(p.series, result)
+	}
+
+	return nil
+}
+
+// csvRow associates the typed values of a row, and the fields they were read from,
+// with its column names.
+type csvRow struct {
+	names  []string
+	fields []string
+	values []interface{}
+}
+
+// index returns the index of a column, identified by name or by zero-based index,
+// or -1 if the row doesn't have it.
+func (r csvRow) index(column string) int {
+	for index, name := range r.names {
+		if name == column {
+			return index
+		}
+	}
+
+	if index, err := strconv.Atoi(column); err == nil && index >= 0 && index < len(r.values) {
+		return index
+	}
+
+	return -1
+}
+
+func (r csvRow) get(column string) (interface{}, bool) {
+	if index := r.index(column); index != -1 {
+		return r.values[index], true
+	}
+
+	return nil, false
+}
+
+// field returns the text of a column, as it appears in the file.
+func (r csvRow) field(column string) (string, bool) {
+	if index := r.index(column); index != -1 {
+		return r.fields[index], true
+	}
+
+	return "", false
+}
+
+// output returns the row in the format used for flow updates, limited to the
+// columns that the plugin has been configured to extract.
+func (r csvRow) output(header bool, columns []string) (interface{}, error) {
+	if len(columns) == 0 {
+		if !header {
+			return r.values, nil
+		}
+
+		result := map[string]interface{}{}
+
+		for index, name := range r.names {
+			result[name] = r.values[index]
+		}
+
+		return result, nil
+	}
+
+	if header {
+		result := map[string]interface{}{}
+
+		for _, column := range columns {
+			value, ok := r.get(column)
+
+			if !ok {
+				return nil, errors.New("Unknown column `" + column + "`")
+			}
+
+			name := column
+
+			if index, err := strconv.Atoi(column); err == nil && index < len(r.names) {
+				name = r.names[index]
+			}
+
+			result[name] = value
+		}
+
+		return result, nil
+	}
+
+	result := []interface{}{}
+
+	for _, column := range columns {
+		value, ok := r.get(column)
+
+		if !ok {
+			return nil, errors.New("Unknown column `" + column + "`")
+		}
+
+		result = append(result, value)
+	}
+
+	return result, nil
+}
+
+// readRows reads the file and returns the rows that match the plugin's filters.
+func (p *CSVPlugin) readRows() ([]csvRow, error) {
+	f, err := os.Open(p.filePath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+
+	reader.Comma = p.delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	names := []string{}
+	rows := []csvRow{}
+
+	first := true
+
+	for {
+		record, err := reader.Read()
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if first && p.header {
+			for _, name := range record {
+				names = append(names, strings.TrimSpace(name))
+			}
+
+			first = false
+			continue
+		}
+
+		first = false
+
+		row := csvRow{names: names, fields: record}
+
+		for _, field := range record {
+			row.values = append(row.values, csvTypedValue(field))
+		}
+
+		matches, err := p.matches(row)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if matches {
+			rows = append(rows, row)
+		}
+	}
+
+	switch {
+	case p.last < 0 && len(rows) > 0:
+		rows = rows[len(rows)-1:]
+
+	case p.last > 0 && len(rows) > p.last:
+		rows = rows[len(rows)-p.last:]
+	}
+
+	return rows, nil
+}
+
+func (p *CSVPlugin) matches(row csvRow) (bool, error) {
+	for _, filter := range p.filters {
+		value, ok := row.get(filter.column)
+
+		if !ok {
+			return false, errors.New("Unknown column `" + filter.column + "` in filter")
+		}
+
+		if !filter.matches(value) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (f csvFilter) matches(value interface{}) bool {
+	switch f.op {
+	case "eq":
+		return csvCompare(value, f.value) == 0
+
+	case "ne":
+		return csvCompare(value, f.value) != 0
+
+	case "gt":
+		return csvCompare(value, f.value) > 0
+
+	case "gte":
+		return csvCompare(value, f.value) >= 0
+
+	case "lt":
+		return csvCompare(value, f.value) < 0
+
+	case "lte":
+		return csvCompare(value, f.value) <= 0
+
+	case "contains":
+		return strings.Contains(fmt.Sprintf("%v", value), fmt.Sprintf("%v", f.value))
+
+	case "matches":
+		return f.regex.MatchString(fmt.Sprintf("%v", value))
+	}
+
+	return false
+}
+
+// csvCompare compares two values numerically if they are both numbers, and as
+// strings otherwise.
+func csvCompare(a, b interface{}) int {
+	if x, ok := a.(float64); ok {
+		if y, ok := b.(float64); ok {
+			switch {
+			case x < y:
+				return -1
+
+			case x > y:
+				return 1
+
+			default:
+				return 0
+			}
+		}
+	}
+
+	return strings.Compare(fmt.Sprintf("%v", a), fmt.Sprintf("%v", b))
+}
+
+// csvTypedValue converts a field into a number, a boolean, or nil if possible,
+// and returns it as a string otherwise.
+func csvTypedValue(field string) interface{} {
+	trimmed := strings.TrimSpace(field)
+
+	if trimmed == "" {
+		return nil
+	}
+
+	if v, err := strconv.ParseFloat(trimmed, 64); err == nil {
+		return v
+	}
+
+	switch strings.ToLower(trimmed) {
+	case "true":
+		return true
+
+	case "false":
+		return false
+	}
+
+	return field
+}
+
+// csvNormalizeValue converts the numeric values found in the configuration file
+// to float64, so that they can be compared with the values extracted from the file.
+func csvNormalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+
+	case int64:
+		return float64(v)
+
+	case float32:
+		return float64(v)
+	}
+
+	return value
+}
+
+var csvTimeFormats = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// parseTime parses the text of a timestamp column. Numeric timestamps are read from
+// the text rather than from the typed value, which may have lost precision.
+func (p *CSVPlugin) parseTime(field string) (time.Time, error) {
+	s := strings.TrimSpace(field)
+
+	if p.timeFormat != "" {
+		return time.Parse(p.timeFormat, s)
+	}
+
+	if ts, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Unix(int64(ts), 0), nil
+	}
+
+	for _, format := range csvTimeFormats {
+		if t, err := time.Parse(format, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, errors.New("Unable to parse the timestamp `" + s + "`")
+}
+
+// appendToSeries pushes the value of each row to the plugin's series.
+func (p *CSVPlugin) appendToSeries(j *job.Job, rows []csvRow) error {
+	context, err := aggregations.GetContext()
+
+	if err != nil {
+		return err
+	}
+
+	defer context.Close()
+
+	if err := context.Begin(); err != nil {
+		return err
+	}
+
+	for _, s := range p.series {
+		series, err := aggregations.GetSeries(context, s.name)
+
+		if err != nil {
+			context.SetError()
+			return err
+		}
+
+		var latest int64 = -1
+
+		if s.timestamp != "" {
+			if last, err := series.Last(); err == nil {
+				switch ts := last["ts"].(type) {
+				case int64:
+					latest = ts
+
+				case time.Time:
+					latest = ts.Unix()
+				}
+			} else if err != io.EOF {
+				context.SetError()
+				return err
+			}
+		}
+
+		count := 0
+
+		for _, row := range rows {
+			value, ok := row.get(s.value)
+
+			if !ok {
+				context.SetError()
+				return errors.New("Unknown column `" + s.value + "` in series `" + s.name + "`")
+			}
+
+			v, ok := value.(float64)
+
+			if !ok {
+				j.Debugf("Skipping non-numeric value %#v for series `%s`", value, s.name)
+				continue
+			}
+
+			var ts *time.Time
+
+			if s.timestamp != "" {
+				rawTs, ok := row.field(s.timestamp)
+
+				if !ok {
+					context.SetError()
+					return errors.New("Unknown column `" + s.timestamp + "` in series `" + s.name + "`")
+				}
+
+				t, err := p.parseTime(rawTs)
+
+				if err != nil {
+					context.SetError()
+					return err
+				}
+
+				if t.Unix() <= latest {
+					continue
+				}
+
+				ts = &t
+			}
+
+			if err := series.Push(ts, v); err != nil {
+				context.SetError()
+				return err
+			}
+
+			count += 1
+		}
+
+		j.Debugf("Appended %d values to series `%s`", count, s.name)
+	}
+
+	return nil
+}
+
+func (p *CSVPlugin) performAllTasks(j *job.Job) {
+	j.Log("Starting CSV plugin...")
+
+	defer p.PluginHelper.TrackTime(j, time.Now(), "CSV plugin completed in %s.")
+
+	rows, err := p.readRows()
+
+	if err != nil {
+		j.ReportError(err)
+		return
+	}
+
+	if len(p.series) > 0 {
+		if err := p.appendToSeries(j, rows); err != nil {
+			j.ReportError(err)
+			return
+		}
+	}
+
+	if p.flow == nil {
+		return
+	}
+
+	data := []interface{}{}
+
+	for _, row := range rows {
+		output, err := row.output(p.header, p.columns)
+
+		if err != nil {
+			j.ReportError(err)
+			return
+		}
+
+		data = append(data, output)
+	}
+
+	var all interface{} = data
+
+	if p.last < 0 {
+		if len(data) == 0 {
+			j.Debugf("No rows match the plugin's filters. Skipping the flow update.")
+			return
+		}
+
+		all = data[0]
+	}
+
+	if err := j.ReadFlow(p.flow); err != nil {
+		j.ReportError(err)
+		return
+	}
+
+	doc, err := json.Marshal(p.flow.Data)
+
+	if err != nil {
+		j.ReportError(err)
+		return
+	}
+
+	patchSource, err := substituteExcelPlaceholders(p.patch, all, data)
+
+	if err != nil {
+		j.ReportError(err)
+		return
+	}
+
+	patch, err := jsonpatch.DecodePatch([]byte(patchSource))
+
+	if err != nil {
+		j.ReportError(err)
+		return
+	}
+
+	doc, err = patch.Apply(doc)
+
+	if err != nil {
+		j.ReportError(err)
+		return
+	}
+
+	err = json.Unmarshal(doc, &p.flow.Data)
+
+	if err != nil {
+		j.ReportError(err)
+		return
+	}
+
+	j.Logf("Posting flow (%s) %s", p.flowTag, p.flow.Id)
+
+	j.PostFlowUpdate(p.flow)
+}
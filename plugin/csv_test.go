@@ -0,0 +1,351 @@
+package plugin
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeTestCSV(t *testing.T, name string, contents string) (string, func()) {
+	dir, err := ioutil.TempDir("", "csv")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, name)
+
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestCSVDelimiter(t *testing.T) {
+	tests := []struct {
+		path      string
+		delimiter interface{}
+		expected  rune
+	}{
+		{"data.csv", nil, ','},
+		{"data.tsv", nil, '\t'},
+		{"DATA.TSV", nil, '\t'},
+		{"data.csv", "tab", '\t'},
+		{"data.csv", `\t`, '\t'},
+		{"data.csv", "\t", '\t'},
+		{"data.csv", ";", ';'},
+		{"data.tsv", "|", '|'},
+	}
+
+	for _, test := range tests {
+		p := &CSVPlugin{filePath: test.path}
+
+		if err := p.parseDelimiter(test.delimiter); err != nil {
+			t.Errorf("%s, %#v: %s", test.path, test.delimiter, err)
+			continue
+		}
+
+		if p.delimiter != test.expected {
+			t.Errorf("%s, %#v: expected %q, got %q", test.path, test.delimiter, test.expected, p.delimiter)
+		}
+	}
+
+	for _, delimiter := range []interface{}{"", ";;", 1} {
+		p := &CSVPlugin{filePath: "data.csv"}
+
+		if err := p.parseDelimiter(delimiter); err == nil {
+			t.Errorf("%#v: expected an error", delimiter)
+		}
+	}
+}
+
+const csvTestFile = `region,total,active,note
+north,120,true,first
+south,80,false,
+east, 200 ,TRUE,"quoted, with comma"
+west,abc,false,last
+`
+
+func TestCSVRows(t *testing.T) {
+	path, cleanup := writeTestCSV(t, "sales.csv", csvTestFile)
+	defer cleanup()
+
+	tests := []struct {
+		name     string
+		header   bool
+		columns  []string
+		filters  []interface{}
+		last     int
+		expected []interface{}
+	}{
+		{
+			name:   "all rows without a header",
+			header: false,
+			expected: []interface{}{
+				[]interface{}{"region", "total", "active", "note"},
+				[]interface{}{"north", 120.0, true, "first"},
+				[]interface{}{"south", 80.0, false, nil},
+				[]interface{}{"east", 200.0, true, "quoted, with comma"},
+				[]interface{}{"west", "abc", false, "last"},
+			},
+		},
+		{
+			name:    "columns by name",
+			header:  true,
+			columns: []string{"region", "total"},
+			expected: []interface{}{
+				map[string]interface{}{"region": "north", "total": 120.0},
+				map[string]interface{}{"region": "south", "total": 80.0},
+				map[string]interface{}{"region": "east", "total": 200.0},
+				map[string]interface{}{"region": "west", "total": "abc"},
+			},
+		},
+		{
+			name:    "columns by index with a header",
+			header:  true,
+			columns: []string{"0", "2"},
+			last:    1,
+			expected: []interface{}{
+				map[string]interface{}{"region": "west", "active": false},
+			},
+		},
+		{
+			name:    "columns by index without a header",
+			columns: []string{"1"},
+			last:    2,
+			expected: []interface{}{
+				[]interface{}{200.0},
+				[]interface{}{"abc"},
+			},
+		},
+		{
+			// Values that aren't numbers are compared as strings, and "abc" > "100"
+			name:    "numeric filter",
+			header:  true,
+			columns: []string{"region"},
+			filters: []interface{}{map[interface{}]interface{}{"column": "total", "op": "gte", "value": 100}},
+			expected: []interface{}{
+				map[string]interface{}{"region": "north"},
+				map[string]interface{}{"region": "east"},
+				map[string]interface{}{"region": "west"},
+			},
+		},
+		{
+			name:    "combined filters",
+			header:  true,
+			columns: []string{"region"},
+			filters: []interface{}{
+				map[interface{}]interface{}{"column": "active", "value": false},
+				map[interface{}]interface{}{"column": "region", "op": "ne", "value": "west"},
+			},
+			expected: []interface{}{
+				map[string]interface{}{"region": "south"},
+			},
+		},
+		{
+			name:    "string filters",
+			header:  true,
+			columns: []string{"region"},
+			filters: []interface{}{
+				map[interface{}]interface{}{"column": "note", "op": "contains", "value": "with"},
+			},
+			expected: []interface{}{
+				map[string]interface{}{"region": "east"},
+			},
+		},
+		{
+			name:    "regular expression filter by index",
+			header:  true,
+			columns: []string{"region"},
+			filters: []interface{}{
+				map[interface{}]interface{}{"column": 0, "op": "matches", "value": "^(north|west)$"},
+			},
+			expected: []interface{}{
+				map[string]interface{}{"region": "north"},
+				map[string]interface{}{"region": "west"},
+			},
+		},
+		{
+			name:    "latest row",
+			header:  true,
+			columns: []string{"note"},
+			last:    -1,
+			expected: []interface{}{
+				map[string]interface{}{"note": "last"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		p := &CSVPlugin{filePath: path, delimiter: ',', header: test.header, columns: test.columns, last: test.last}
+
+		if test.filters != nil {
+			if err := p.parseFilters(test.filters); err != nil {
+				t.Errorf("%s: %s", test.name, err)
+				continue
+			}
+		}
+
+		rows, err := p.readRows()
+
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		result := []interface{}{}
+
+		for _, row := range rows {
+			output, err := row.output(p.header, p.columns)
+
+			if err != nil {
+				t.Errorf("%s: %s", test.name, err)
+				break
+			}
+
+			result = append(result, output)
+		}
+
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("%s: expected %#v, got %#v", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestCSVReadsTSVFiles(t *testing.T) {
+	path, cleanup := writeTestCSV(t, "sales.tsv", "region\ttotal\nnorth\t1,200\n")
+	defer cleanup()
+
+	p := &CSVPlugin{filePath: path, header: true}
+
+	if err := p.parseDelimiter(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := p.readRows()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 1 || !reflect.DeepEqual(rows[0].values, []interface{}{"north", "1,200"}) {
+		t.Errorf("Unexpected rows %#v", rows)
+	}
+}
+
+func TestCSVInvalidConfiguration(t *testing.T) {
+	path, cleanup := writeTestCSV(t, "sales.csv", csvTestFile)
+	defer cleanup()
+
+	for _, filters := range []interface{}{
+		"total > 0",
+		[]interface{}{"total"},
+		[]interface{}{map[interface{}]interface{}{"op": "eq", "value": 1}},
+		[]interface{}{map[interface{}]interface{}{"column": "total", "op": "between", "value": 1}},
+		[]interface{}{map[interface{}]interface{}{"column": "total", "op": "matches", "value": "("}},
+	} {
+		if err := (&CSVPlugin{}).parseFilters(filters); err == nil {
+			t.Errorf("%#v: expected an error", filters)
+		}
+	}
+
+	p := &CSVPlugin{filePath: path, delimiter: ',', header: true}
+
+	if err := p.parseFilters([]interface{}{map[interface{}]interface{}{"column": "missing", "value": 1}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := p.readRows(); err == nil {
+		t.Error("Expected an error for a filter on an unknown column")
+	}
+
+	p = &CSVPlugin{filePath: path, delimiter: ',', header: true}
+
+	rows, err := p.readRows()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := rows[0].output(true, []string{"missing"}); err == nil {
+		t.Error("Expected an error for an unknown column")
+	}
+}
+
+func TestCSVParseTime(t *testing.T) {
+	tests := []struct {
+		format   string
+		field    string
+		expected time.Time
+	}{
+		{"", "1400000000", time.Unix(1400000000, 0)},
+		{"", " 1400000000 ", time.Unix(1400000000, 0)},
+		{"", "2015-03-04T05:06:07Z", time.Date(2015, 3, 4, 5, 6, 7, 0, time.UTC)},
+		{"", "2015-03-04T05:06:07+02:00", time.Date(2015, 3, 4, 3, 6, 7, 0, time.UTC)},
+		{"", "2015-03-04 05:06:07", time.Date(2015, 3, 4, 5, 6, 7, 0, time.UTC)},
+		{"", "2015-03-04", time.Date(2015, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{"02/01/2006", "04/03/2015", time.Date(2015, 3, 4, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, test := range tests {
+		p := &CSVPlugin{timeFormat: test.format}
+
+		result, err := p.parseTime(test.field)
+
+		if err != nil {
+			t.Errorf("%q: %s", test.field, err)
+			continue
+		}
+
+		if !result.Equal(test.expected) {
+			t.Errorf("%q: expected %s, got %s", test.field, test.expected, result)
+		}
+	}
+
+	for _, test := range []struct{ format, field string }{
+		{"", "yesterday"},
+		{"", ""},
+		{"02/01/2006", "2015-03-04"},
+	} {
+		if _, err := (&CSVPlugin{timeFormat: test.format}).parseTime(test.field); err == nil {
+			t.Errorf("%q: expected an error", test.field)
+		}
+	}
+}
+
+func TestCSVPlaceholders(t *testing.T) {
+	data := []interface{}{
+		map[string]interface{}{"name": "$$1", "total": 10.0},
+		map[string]interface{}{"name": "b", "total": 20.0},
+	}
+
+	tests := []struct {
+		patch    string
+		all      interface{}
+		expected string
+	}{
+		{`{"value": "$$#"}`, data, `{"value": [{"name":"$$1","total":10},{"name":"b","total":20}]}`},
+		{`{"value": "$$#"}`, data[1], `{"value": {"name":"b","total":20}}`},
+		{`{"value": "$$0"}`, data, `{"value": {"name":"$$1","total":10}}`},
+		{`{"value": "Row: $$1"}`, data, `{"value": "Row: {\"name\":\"b\",\"total\":20}"}`},
+		{`{"value": "$$2"}`, data, `{"value": "$$2"}`},
+	}
+
+	for _, test := range tests {
+		result, err := substituteExcelPlaceholders(test.patch, test.all, data)
+
+		if err != nil {
+			t.Errorf("%s: %s", test.patch, err)
+			continue
+		}
+
+		if result != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.patch, test.expected, result)
+		}
+	}
+}
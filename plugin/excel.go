@@ -10,6 +10,7 @@ import (
 	"github.com/telemetryapp/gotelemetry_agent/agent/config"
 	"github.com/telemetryapp/gotelemetry_agent/agent/job"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -32,13 +33,16 @@ func ExcelPluginFactory() job.PluginInstance {
 // populate a Telemetry flow.
 type ExcelPlugin struct {
 	*job.PluginHelper
-	filePath string
-	refresh  time.Duration
-	cells    []excelCellReference
-	patch    string
-	flowTag  string
-	variant  string
-	flow     *gotelemetry.Flow
+	filePath   string
+	refresh    time.Duration
+	sheetName  string
+	sheetIndex int
+	ranges     []excelRange
+	header     bool
+	patch      string
+	flowTag    string
+	variant    string
+	flow       *gotelemetry.Flow
 }
 
 // Init initializes the plugin.
@@ -49,7 +53,12 @@ type ExcelPlugin struct {
 //
 // - observe                      Whether the plugin should observe the file for changes, and run whenever changes are detected
 //
-// - source                       The data to be extracted; a comma-separated list of one or more cells (e.g.: “A12”) or monodimensional cell ranges (e.g.: “A1:A14”). The plugin supports both string and numeric values.
+// - source                       The data to be extracted; a comma-separated list of one or more cells (e.g.: “A12”), cell ranges (e.g.: “A1:A14” or “A1:C14”), or named ranges defined in the workbook. Cells and ranges can be prefixed with the name of a sheet (e.g.: “Sales!A1:C14”). The plugin supports both string and numeric values.
+//
+// - sheet                        The name or zero-based index of the sheet from which data is extracted. Default: 0
+//
+//   - header                       If true, the first row of every table is used as a list of column names, and the
+//     remaining rows are returned as objects keyed by those names. Default: false
 //
 // - flow_tag                     The tag of the flow to populate
 //
@@ -63,6 +72,33 @@ type ExcelPlugin struct {
 // be replaced by the data extracted from your Excel sheet at runtime. You can also
 // use $$n as a placeholder that will be replaced by an individual value extracted from the
 // sheet.
+//
+// A string that contains nothing but a placeholder, like "$$0", is replaced by the value
+// itself, so that numbers stay numbers and tables become arrays; earlier versions of the
+// plugin produced the text of the value instead. A placeholder that is part of a longer
+// string, like "Total: $$0", is replaced by the text of the value.
+//
+// Cells are referenced as in Excel: “A1” is the first cell of the sheet, and “AA1” the
+// 27th cell of its first row. Earlier versions of the plugin read the row below single
+// cells (e.g.: “A12” read the cell A13), computed the wrong column for references with
+// more than one letter, and couldn't read horizontal ranges; configurations that worked
+// around these issues must be updated.
+//
+// Cells whose type isn't supported, like dates and errors, are reported as errors and
+// extracted as null values.
+//
+// Cells and single-row or single-column ranges contribute one value per cell to the
+// extracted data. A range that spans multiple rows and columns (a table) contributes a
+// single value instead: an array of rows, each of which is an array of cell values, or,
+// if `header` is true, an array of objects. For example, given:
+//
+//	source: "Leaderboard!A1:B11"
+//	header: true
+//	patch:
+//	  - { "op": "replace", "path": "/values", "value": "$$0" }
+//
+// and a sheet whose first row contains the labels “name” and “score”, $$0 is replaced
+// by an array like [{"name": "Alice", "score": 12}, ...].
 func (p *ExcelPlugin) Init(job *job.Job) error {
 	var err error
 
@@ -72,13 +108,37 @@ func (p *ExcelPlugin) Init(job *job.Job) error {
 	p.flowTag = c["flow_tag"].(string)
 	p.variant = c["variant"].(string)
 
-	p.cells, err = p.parseRange(c["source"].(string))
+	p.ranges, err = p.parseRange(c["source"].(string))
 
 	if err != nil {
 		job.ReportError(err)
 		return err
 	}
 
+	switch sheet := c["sheet"].(type) {
+	case nil:
+		// Use the first sheet
+
+	case string:
+		p.sheetName = sheet
+
+	case int:
+		if sheet < 0 {
+			return errors.New("The `sheet` property cannot be negative.")
+		}
+
+		p.sheetIndex = sheet
+
+	default:
+		return errors.New("The `sheet` property must be either the name or the index of a sheet.")
+	}
+
+	if header, ok := c["header"]; ok {
+		if p.header, ok = header.(bool); !ok {
+			return errors.New("The `header` property must be a boolean.")
+		}
+	}
+
 	patch, err := json.Marshal(config.MapFromYaml(c["patch"]))
 
 	if err != nil {
@@ -105,65 +165,132 @@ func (p *ExcelPlugin) Init(job *job.Job) error {
 	return nil
 }
 
-var excelRangeRegex = regexp.MustCompile(`^\s*([A-Za-z]+)(\d+)\s*[-:]\s*([A-Za-z]+)(\d+)\s*`)
-var excelCellRegex = regexp.MustCompile(`^\s*([A-Za-z]+)(\d+)\s*$`)
+func (p *ExcelPlugin) parseRange(rangeSpec string) ([]excelRange, error) {
+	result := []excelRange{}
 
-func (p *ExcelPlugin) parseRange(rangeSpec string) ([]excelCellReference, error) {
-	result := []excelCellReference{}
-	ranges := strings.Split(rangeSpec, ",")
+	for _, r := range strings.Split(rangeSpec, ",") {
+		parsed, err := parseExcelRange(r, true)
 
-	for _, r := range ranges {
-		rangeMatches := excelRangeRegex.FindStringSubmatch(r)
-		cellMatches := excelCellRegex.FindStringSubmatch(r)
+		if err != nil {
+			return result, err
+		}
 
-		switch {
-		case len(rangeMatches) > 0:
-			startCell := newExcelCellReference(rangeMatches[1], rangeMatches[2])
-			endCell := newExcelCellReference(rangeMatches[3], rangeMatches[4])
+		result = append(result, parsed)
+	}
 
-			if startCell.Column-endCell.Column != 0 && startCell.Row-endCell.Row != 0 {
-				return result, errors.New("The range expression `" + r + "` does not represent a mono-dimensional block of cells.")
-			}
+	return result, nil
+}
 
-			if startCell.Column-endCell.Column == 0 {
-				var start, end int
+// resolveRange turns a named range into a regular range by looking up its definition
+// in the workbook. Ranges that aren't named are returned unchanged.
+func (p *ExcelPlugin) resolveRange(f *xlsx.File, r excelRange) (excelRange, error) {
+	if r.name == "" {
+		return r, nil
+	}
 
-				if startCell.Row < endCell.Row {
-					start = startCell.Row - 1
-					end = endCell.Row
-				} else {
-					start = endCell.Row - 1
-					end = startCell.Row
-				}
+	for _, definedName := range f.DefinedNames {
+		if definedName.Name != r.name {
+			continue
+		}
 
-				for index := start; index < end; index++ {
-					result = append(result, excelCellReference{Row: index, Column: startCell.Column})
-				}
-			} else {
-				var start, end int
-
-				if startCell.Column < endCell.Column {
-					start = startCell.Column - 1
-					end = endCell.Column
-				} else {
-					start = endCell.Column - 1
-					end = startCell.Column
-				}
+		if strings.Contains(definedName.Data, ",") {
+			return r, errors.New("The named range `" + r.name + "` contains multiple areas, which are not supported.")
+		}
 
-				for index := start; index < end; index++ {
-					result = append(result, excelCellReference{Row: startCell.Row, Column: index})
-				}
+		result, err := parseExcelRange(definedName.Data, false)
+
+		if err != nil {
+			return r, errors.New("Unable to parse the definition of the named range `" + r.name + "`: " + err.Error())
+		}
+
+		return result, nil
+	}
+
+	return r, errors.New("The workbook does not contain a named range called `" + r.name + "`")
+}
+
+func (p *ExcelPlugin) sheet(f *xlsx.File, name string) (*xlsx.Sheet, error) {
+	if name == "" {
+		name = p.sheetName
+	}
+
+	if name != "" {
+		if sheet, ok := f.Sheet[name]; ok {
+			return sheet, nil
+		}
+
+		return nil, errors.New("The workbook does not contain a sheet called `" + name + "`")
+	}
+
+	if p.sheetIndex >= len(f.Sheets) {
+		return nil, errors.New(fmt.Sprintf("The workbook does not contain a sheet with index %d", p.sheetIndex))
+	}
+
+	return f.Sheets[p.sheetIndex], nil
+}
+
+// cellValue returns the value of a cell. Cells of types that the plugin doesn't support,
+// like dates and errors, are reported and treated as empty, so that the other values can
+// still be extracted and the placeholders keep referring to the same cells.
+func (p *ExcelPlugin) cellValue(j *job.Job, c *xlsx.Cell) (interface{}, error) {
+	switch c.Type() {
+	case xlsx.CellTypeBool:
+		return c.Bool(), nil
+
+	case xlsx.CellTypeNumeric:
+		return c.Float()
+
+	case xlsx.CellTypeString, xlsx.CellTypeStringFormula, xlsx.CellTypeInline:
+		return c.String(), nil
+
+	default:
+		j.ReportError(errors.New(fmt.Sprintf("Unable to handle value of type %d", c.Type())))
+		return nil, nil
+	}
+}
+
+// extractTable returns the contents of a table either as an array of rows or, if the
+// plugin is configured to use a header row, as an array of objects.
+func (p *ExcelPlugin) extractTable(j *job.Job, sheet *xlsx.Sheet, r excelRange) (interface{}, error) {
+	rows := []interface{}{}
+	keys := []string{}
+
+	for index, cells := range r.cells() {
+		row := []interface{}{}
+
+		for _, cell := range cells {
+			value, err := p.cellValue(j, sheet.Cell(cell.Row, cell.Column))
+
+			if err != nil {
+				return nil, err
 			}
 
-		case len(cellMatches) > 0:
-			result = append(result, newExcelCellReference(cellMatches[1], cellMatches[2]))
+			row = append(row, value)
+		}
+
+		if !p.header {
+			rows = append(rows, row)
+			continue
+		}
+
+		if index == 0 {
+			for _, value := range row {
+				keys = append(keys, fmt.Sprintf("%v", value))
+			}
 
-		default:
-			return result, errors.New("Unable to parse range expression `" + r + "`")
+			continue
 		}
+
+		object := map[string]interface{}{}
+
+		for column, value := range row {
+			object[keys[column]] = value
+		}
+
+		rows = append(rows, object)
 	}
 
-	return result, nil
+	return rows, nil
 }
 
 func (p *ExcelPlugin) performAllTasks(j *job.Job) {
@@ -180,28 +307,44 @@ func (p *ExcelPlugin) performAllTasks(j *job.Job) {
 
 	data := []interface{}{}
 
-	sheet := f.Sheets[0]
+	for _, r := range p.ranges {
+		r, err := p.resolveRange(f, r)
+
+		if err != nil {
+			j.ReportError(err)
+			return
+		}
+
+		sheet, err := p.sheet(f, r.sheetName)
 
-	for _, cell := range p.cells {
-		c := sheet.Cell(cell.Row, cell.Column)
+		if err != nil {
+			j.ReportError(err)
+			return
+		}
 
-		switch c.Type() {
-		case xlsx.CellTypeBool:
-			data = append(data, c.Bool())
+		if r.isTable() {
+			table, err := p.extractTable(j, sheet, r)
 
-		case xlsx.CellTypeNumeric:
-			if v, err := c.Float(); err == nil {
-				data = append(data, v)
-			} else {
+			if err != nil {
 				j.ReportError(err)
 				return
 			}
 
-		case xlsx.CellTypeString:
-			data = append(data, c.String())
+			data = append(data, table)
+			continue
+		}
 
-		default:
-			j.ReportError(errors.New(fmt.Sprintf("Unable to handle value of type %s", c.Type())))
+		for _, cells := range r.cells() {
+			for _, cell := range cells {
+				value, err := p.cellValue(j, sheet.Cell(cell.Row, cell.Column))
+
+				if err != nil {
+					j.ReportError(err)
+					return
+				}
+
+				data = append(data, value)
+			}
 		}
 	}
 
@@ -217,33 +360,20 @@ func (p *ExcelPlugin) performAllTasks(j *job.Job) {
 		return
 	}
 
-	marshalled, err := json.Marshal(data)
+	patchSource, err := substituteExcelPlaceholders(p.patch, data, data)
 
 	if err != nil {
 		j.ReportError(err)
 		return
 	}
 
-	patchSource := strings.Replace(p.patch, "$$#", string(marshalled), -1)
-
-	for index, value := range data {
-		marshalled, err := json.Marshal(value)
-
-		if err != nil {
-			j.ReportError(err)
-			return
-		}
+	patch, err := jsonpatch.DecodePatch([]byte(patchSource))
 
-		patchSource = strings.Replace(
-			patchSource,
-			fmt.Sprintf("$$%d", index),
-			string(marshalled),
-			-1,
-		)
+	if err != nil {
+		j.ReportError(err)
+		return
 	}
 
-	patch, err := jsonpatch.DecodePatch([]byte(patchSource))
-
 	doc, err = patch.Apply(doc)
 
 	if err != nil {
@@ -261,3 +391,76 @@ func (p *ExcelPlugin) performAllTasks(j *job.Job) {
 
 	j.PostFlowUpdate(p.flow)
 }
+
+// excelPlaceholderRegex matches either a JSON string that contains nothing but a
+// placeholder, or a placeholder anywhere else; the first alternative wins when both
+// could match at the same position.
+var excelPlaceholderRegex = regexp.MustCompile(`"\$\$(#|\d+)"|\$\$(#|\d+)`)
+
+// substituteExcelPlaceholders replaces the placeholders in the JSON source of a patch
+// with extracted data: "$$#" with all, and "$$n" with the nth element of data. A string
+// that consists of nothing but a placeholder is replaced by the JSON encoding of the
+// value, so that numbers, booleans, and tables keep their type; a placeholder that is
+// part of a longer string is replaced by the text of the value. Placeholders that refer
+// to values that don't exist are left alone.
+//
+// The patch is scanned only once, so placeholders that appear in the inserted values
+// are never replaced themselves.
+func substituteExcelPlaceholders(patch string, all interface{}, data []interface{}) (string, error) {
+	var err error
+
+	value := func(placeholder string) (interface{}, bool) {
+		if placeholder == "#" {
+			return all, true
+		}
+
+		index, _ := strconv.Atoi(placeholder)
+
+		if index >= len(data) {
+			return nil, false
+		}
+
+		return data[index], true
+	}
+
+	marshal := func(v interface{}) string {
+		result, e := json.Marshal(v)
+
+		if e != nil {
+			err = e
+		}
+
+		return string(result)
+	}
+
+	patch = excelPlaceholderRegex.ReplaceAllStringFunc(patch, func(match string) string {
+		submatches := excelPlaceholderRegex.FindStringSubmatch(match)
+
+		if submatches[1] != "" {
+			if v, ok := value(submatches[1]); ok {
+				return marshal(v)
+			}
+
+			return match
+		}
+
+		v, ok := value(submatches[2])
+
+		if !ok {
+			return match
+		}
+
+		text, isString := v.(string)
+
+		if !isString {
+			text = marshal(v)
+		}
+
+		// The placeholder is inside a JSON string, so the text must be escaped
+		escaped := marshal(text)
+
+		return escaped[1 : len(escaped)-1]
+	})
+
+	return patch, err
+}
@@ -1,27 +1,31 @@
 package plugin
 
 import (
-	"math"
+	"errors"
+	"regexp"
 	"strconv"
 	"strings"
 )
 
+// Struct excelCellReference identifies a cell by its zero-based row and column.
 type excelCellReference struct {
 	Row    int
 	Column int
 }
 
+// newExcelCellReference converts the column letters and the one-based row number of an
+// A1-style reference into a zero-based excelCellReference.
 func newExcelCellReference(column, row string) excelCellReference {
 	result := excelCellReference{}
 
 	column = strings.ToUpper(column)
 
-	l := len(column) - 1
-
-	for index, char := range column {
-		result.Column += int(float64((char-'A')+1)*(math.Pow(26, float64(l-index)))) - 1
+	for _, char := range column {
+		result.Column = result.Column*26 + int(char-'A') + 1
 	}
 
+	result.Column -= 1
+
 	var err error
 
 	result.Row, err = strconv.Atoi(row)
@@ -30,5 +34,101 @@ func newExcelCellReference(column, row string) excelCellReference {
 		panic(err) // This should never happen, since we always come here from a regex that only matches digits
 	}
 
+	result.Row -= 1
+
+	return result
+}
+
+// Struct excelRange represents a block of cells, which can be a single cell, a
+// single row or column, or a table.
+//
+// If name is set, the range refers to a named range defined in the workbook, which
+// is resolved every time the workbook is read.
+type excelRange struct {
+	name      string
+	sheetName string // The sheet on which the range lives; empty for the plugin's default sheet
+	start     excelCellReference
+	end       excelCellReference
+}
+
+var excelRangeRegex = regexp.MustCompile(`^\s*([A-Za-z]+)(\d+)\s*[-:]\s*([A-Za-z]+)(\d+)\s*$`)
+var excelCellRegex = regexp.MustCompile(`^\s*([A-Za-z]+)(\d+)\s*$`)
+var excelNameRegex = regexp.MustCompile(`^\s*([A-Za-z_\\][A-Za-z0-9_.\\]*)\s*$`)
+
+// parseExcelRange parses a single reference like `A1`, `A1:C10`, `Sheet1!A1:C10`,
+// or `'My Sheet'!$A$1:$C$10`. If allowNames is true, references that look like
+// identifiers are treated as named ranges.
+func parseExcelRange(spec string, allowNames bool) (excelRange, error) {
+	result := excelRange{}
+
+	spec = strings.TrimSpace(spec)
+
+	if index := strings.LastIndex(spec, "!"); index > -1 {
+		result.sheetName = strings.Trim(spec[:index], "'")
+		spec = spec[index+1:]
+	}
+
+	spec = strings.Replace(spec, "$", "", -1)
+
+	rangeMatches := excelRangeRegex.FindStringSubmatch(spec)
+	cellMatches := excelCellRegex.FindStringSubmatch(spec)
+
+	switch {
+	case len(rangeMatches) > 0:
+		startCell := newExcelCellReference(rangeMatches[1], rangeMatches[2])
+		endCell := newExcelCellReference(rangeMatches[3], rangeMatches[4])
+
+		result.start = excelCellReference{Row: minInt(startCell.Row, endCell.Row), Column: minInt(startCell.Column, endCell.Column)}
+		result.end = excelCellReference{Row: maxInt(startCell.Row, endCell.Row), Column: maxInt(startCell.Column, endCell.Column)}
+
+	case len(cellMatches) > 0:
+		result.start = newExcelCellReference(cellMatches[1], cellMatches[2])
+		result.end = result.start
+
+	case allowNames && result.sheetName == "" && excelNameRegex.MatchString(spec):
+		result.name = strings.TrimSpace(spec)
+
+	default:
+		return result, errors.New("Unable to parse range expression `" + spec + "`")
+	}
+
+	return result, nil
+}
+
+// isTable returns true if the range spans more than one row and more than one column.
+func (r excelRange) isTable() bool {
+	return r.start.Row != r.end.Row && r.start.Column != r.end.Column
+}
+
+// cells returns the references to all the cells in a range, row by row.
+func (r excelRange) cells() [][]excelCellReference {
+	result := [][]excelCellReference{}
+
+	for row := r.start.Row; row <= r.end.Row; row++ {
+		cols := []excelCellReference{}
+
+		for col := r.start.Column; col <= r.end.Column; col++ {
+			cols = append(cols, excelCellReference{Row: row, Column: col})
+		}
+
+		result = append(result, cols)
+	}
+
 	return result
 }
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
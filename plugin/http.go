@@ -0,0 +1,514 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/evanphx/json-patch"
+	"github.com/telemetryapp/gotelemetry"
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/config"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions"
+	"github.com/telemetryapp/gotelemetry_agent/agent/job"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// init() registers this plugin with the Plugin Manager.
+func init() {
+	job.RegisterPlugin("com.telemetryapp.http", HTTPPluginFactory)
+}
+
+// Func HTTPPluginFactory generates a blank plugin instance of the
+// `com.telemetryapp.http` plugin
+func HTTPPluginFactory() job.PluginInstance {
+	return &HTTPPlugin{
+		PluginHelper: job.NewPluginHelper(),
+	}
+}
+
+// Struct HTTPPlugin polls an HTTP endpoint that returns JSON, extracts values from
+// the response, and uses them to populate a flow.
+//
+// For configuration parameters, see Init()
+type HTTPPlugin struct {
+	*job.PluginHelper
+	url        string
+	method     string
+	headers    map[string]string
+	body       string
+	username   string
+	password   string
+	token      string
+	client     *http.Client
+	extract    map[string]*jsonPath
+	patch      string
+	expression interface{}
+	flowTag    string
+	variant    string
+	flow       *gotelemetry.Flow
+}
+
+// Function Init initializes the plugin.
+//
+// The required configuration parameters are:
+//
+// - url                          The URL of the endpoint
+//
+// - method                       The HTTP method. Default: GET
+//
+// - headers                      An optional map of headers to send with the request
+//
+//   - body                         An optional request body. If it is not a string, it is encoded as JSON, and the
+//     Content-Type header defaults to application/json
+//
+//   - auth                         Optional credentials: either { username: ..., password: ... } for basic
+//     authentication, or { token: ... } for bearer authentication
+//
+// - timeout                      The number of seconds after which the request is abandoned. Default: 30
+//
+// - refresh                      The number of seconds between subsequent executions of the plugin. Default: never
+//
+//   - extract                      A map of names to JSONPath-style selectors (e.g.: `$.data.items[0].value`) that
+//     are applied to the response
+//
+// - flow_tag                     The tag of the flow to populate
+//
+// - variant                      The variant of the flow
+//
+// - template                     A template that will be used to populate the flow when it is created
+//
+// - patch                        A JSON Patch payload that describes how the extracted values must be applied to the flow
+//
+//   - expression                   Alternatively to `patch`, a payload that is evaluated with the agent's expression
+//     language and used to PATCH the flow, like the output of a process job
+//
+// In both `patch` and `expression`, you can use "$$name" as a placeholder for the value
+// extracted by the selector called `name`, and "$$#" as a placeholder for the entire response.
+// In an expression, the extracted values are also available as variables, which can be read
+// with $var; they are treated as data, and never evaluated as expressions.
+//
+// The selectors support `$` for the root of the document, `.name` or `['name']` to select a
+// property, `[n]` to select an element of an array (negative indices count from the end),
+// and `[*]` or `.*` to select every element of an array or object.
+//
+// A response whose status code is not in the 2xx range is reported as an error, and the
+// flow is not updated.
+//
+// For example:
+//
+//	jobs:
+//	  - id: Open tickets
+//	    plugin: com.telemetryapp.http
+//	    config:
+//	      url: https://support.example.com/api/stats
+//	      headers:
+//	        Accept: application/json
+//	      auth:
+//	        token: $SUPPORT_API_TOKEN
+//	      refresh: 60
+//	      extract:
+//	        open: $.tickets.open
+//	        history: $.tickets.daily[*].count
+//	      flow_tag: open_tickets
+//	      expression:
+//	        value: "$$open"
+//	        sparkline: "$$history"
+//	        total: { "$add": { "left": "$$open", "right": 1 } }
+//
+// Values in `url`, `headers`, and `auth` are expanded using environment variables.
+func (p *HTTPPlugin) Init(job *job.Job) error {
+	var ok bool
+
+	c := job.Config()
+
+	p.url, ok = c["url"].(string)
+
+	if !ok {
+		return errors.New("The required `url` property (`string`) is either missing or of the wrong type.")
+	}
+
+	p.url = os.ExpandEnv(p.url)
+
+	p.method = "GET"
+
+	if method, ok := c["method"].(string); ok {
+		p.method = strings.ToUpper(method)
+	}
+
+	p.headers = map[string]string{}
+
+	if headers, ok := config.MapFromYaml(c["headers"]).(map[string]interface{}); ok {
+		for name, value := range headers {
+			p.headers[name] = os.ExpandEnv(fmt.Sprintf("%v", value))
+		}
+	} else if _, ok := c["headers"]; ok {
+		return errors.New("The `headers` property must be a map.")
+	}
+
+	switch body := c["body"].(type) {
+	case nil:
+		// No body
+
+	case string:
+		p.body = body
+
+	default:
+		b, err := json.Marshal(config.MapFromYaml(body))
+
+		if err != nil {
+			return err
+		}
+
+		p.body = string(b)
+
+		if _, ok := p.headers["Content-Type"]; !ok {
+			p.headers["Content-Type"] = "application/json"
+		}
+	}
+
+	if auth, ok := config.MapFromYaml(c["auth"]).(map[string]interface{}); ok {
+		if token, ok := auth["token"].(string); ok {
+			p.token = os.ExpandEnv(token)
+		} else if username, ok := auth["username"].(string); ok {
+			p.username = os.ExpandEnv(username)
+
+			if password, ok := auth["password"].(string); ok {
+				p.password = os.ExpandEnv(password)
+			}
+		} else {
+			return errors.New("The `auth` property must contain either a `token` or a `username`.")
+		}
+	} else if _, ok := c["auth"]; ok {
+		return errors.New("The `auth` property must be a map.")
+	}
+
+	timeout := 30 * time.Second
+
+	if t, ok := c["timeout"].(int); ok {
+		if t <= 0 {
+			return errors.New("Invalid timeout")
+		}
+
+		timeout = time.Duration(t) * time.Second
+	}
+
+	p.client = &http.Client{Timeout: timeout}
+
+	p.extract = map[string]*jsonPath{}
+
+	if extract, ok := config.MapFromYaml(c["extract"]).(map[string]interface{}); ok {
+		for name, selector := range extract {
+			selector, ok := selector.(string)
+
+			if !ok {
+				return errors.New("The selector for `" + name + "` must be a string.")
+			}
+
+			path, err := compileJSONPath(selector)
+
+			if err != nil {
+				return err
+			}
+
+			p.extract[name] = path
+		}
+	} else if _, ok := c["extract"]; ok {
+		return errors.New("The `extract` property must be a map.")
+	}
+
+	p.flowTag, ok = c["flow_tag"].(string)
+
+	if !ok {
+		return errors.New("The required `flow_tag` property (`string`) is either missing or of the wrong type.")
+	}
+
+	_, hasPatch := c["patch"]
+	expression, hasExpression := c["expression"]
+
+	switch {
+	case hasPatch && hasExpression:
+		return errors.New("The `patch` and `expression` properties cannot be used together.")
+
+	case hasPatch:
+		p.variant, ok = c["variant"].(string)
+
+		if !ok {
+			return errors.New("The required `variant` property (`string`) is either missing or of the wrong type.")
+		}
+
+		patch, err := json.Marshal(config.MapFromYaml(c["patch"]))
+
+		if err != nil {
+			job.ReportError(err)
+			return err
+		}
+
+		p.patch = string(patch)
+
+		p.flow, err = job.GetOrCreateFlow(p.flowTag, p.variant, c["template"])
+
+		if err != nil {
+			return err
+		}
+
+	case hasExpression:
+		// Round-trip the expression through JSON, so that numbers are float64 values,
+		// as the expression functions expect.
+
+		source, err := json.Marshal(config.MapFromYaml(expression))
+
+		if err != nil {
+			return err
+		}
+
+		if err := json.Unmarshal(source, &p.expression); err != nil {
+			return err
+		}
+
+		template, templateOK := c["template"]
+		variant, variantOK := c["variant"].(string)
+
+		if variantOK && templateOK {
+			if _, err := job.GetOrCreateFlow(p.flowTag, variant, template); err != nil {
+				return err
+			}
+		}
+
+	default:
+		return errors.New("Either a `patch` or an `expression` property must be provided.")
+	}
+
+	if refresh, ok := c["refresh"].(int); ok {
+		p.PluginHelper.AddTaskWithClosure(p.performAllTasks, time.Duration(refresh)*time.Second)
+	} else {
+		p.PluginHelper.AddTaskWithClosure(p.performAllTasks, 0)
+	}
+
+	return nil
+}
+
+// fetch performs the request and decodes its response.
+func (p *HTTPPlugin) fetch() (interface{}, error) {
+	var body io.Reader
+
+	if p.body != "" {
+		body = bytes.NewBufferString(p.body)
+	}
+
+	req, err := http.NewRequest(p.method, p.url, body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for name, value := range p.headers {
+		req.Header.Set(name, value)
+	}
+
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	} else if p.username != "" {
+		req.SetBasicAuth(p.username, p.password)
+	}
+
+	res, err := p.client.Do(req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, &HTTPError{
+			StatusCode: res.StatusCode,
+			Status:     res.Status,
+		}
+	}
+
+	data, err := ioutil.ReadAll(res.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result interface{}
+
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, errors.New("Unable to decode the response as JSON: " + err.Error())
+	}
+
+	return result, nil
+}
+
+// values applies the plugin's selectors to a response.
+func (p *HTTPPlugin) values(response interface{}) (map[string]interface{}, error) {
+	result := map[string]interface{}{"#": response}
+
+	for name, path := range p.extract {
+		value, err := path.Evaluate(response)
+
+		if err != nil {
+			return nil, err
+		}
+
+		result[name] = value
+	}
+
+	return result, nil
+}
+
+// substitutePlaceholders returns a copy of an expression in which every string of the form
+// "$$name", where name is one of the keys of values, has been replaced by a call to $var
+// that reads the corresponding variable. The values themselves never become part of the
+// expression, so that data received from the remote server is not evaluated as a function
+// call.
+func substitutePlaceholders(expression interface{}, values map[string]interface{}) interface{} {
+	switch e := expression.(type) {
+	case map[string]interface{}:
+		result := map[string]interface{}{}
+
+		for key, value := range e {
+			result[key] = substitutePlaceholders(value, values)
+		}
+
+		return result
+
+	case []interface{}:
+		result := []interface{}{}
+
+		for _, value := range e {
+			result = append(result, substitutePlaceholders(value, values))
+		}
+
+		return result
+
+	case string:
+		if strings.HasPrefix(e, "$$") {
+			if _, ok := values[e[2:]]; ok {
+				return map[string]interface{}{"$var": e[2:]}
+			}
+		}
+	}
+
+	return expression
+}
+
+func (p *HTTPPlugin) applyPatch(j *job.Job, values map[string]interface{}) error {
+	if err := j.ReadFlow(p.flow); err != nil {
+		return err
+	}
+
+	doc, err := json.Marshal(p.flow.Data)
+
+	if err != nil {
+		return err
+	}
+
+	patchSource := p.patch
+
+	for name, value := range values {
+		v, err := json.Marshal(value)
+
+		if err != nil {
+			return err
+		}
+
+		patchSource = strings.Replace(patchSource, fmt.Sprintf(`"$$%s"`, name), string(v), -1)
+	}
+
+	patch, err := jsonpatch.DecodePatch([]byte(patchSource))
+
+	if err != nil {
+		return err
+	}
+
+	doc, err = patch.Apply(doc)
+
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(doc, &p.flow.Data); err != nil {
+		return err
+	}
+
+	j.Logf("Posting flow %s (%s)", p.flowTag, p.flow.Id)
+
+	j.PostFlowUpdate(p.flow)
+
+	return nil
+}
+
+func (p *HTTPPlugin) applyExpression(j *job.Job, values map[string]interface{}) error {
+	context, err := aggregations.GetContext()
+
+	if err != nil {
+		return err
+	}
+
+	defer context.Close()
+
+	for name, value := range values {
+		context.SetVariable(name, value)
+	}
+
+	result, err := functions.Parse(context, substitutePlaceholders(p.expression, values))
+
+	if err != nil {
+		context.SetError()
+		return err
+	}
+
+	data, ok := result.(map[string]interface{})
+
+	if !ok {
+		context.SetError()
+		return errors.New(fmt.Sprintf("The expression must evaluate to an object, not %#v", result))
+	}
+
+	j.Debugf("Posting flow %s", p.flowTag)
+
+	j.QueueDataUpdate(p.flowTag, data, gotelemetry.BatchTypePATCH)
+
+	return nil
+}
+
+func (p *HTTPPlugin) performAllTasks(j *job.Job) {
+	j.Debugf("Starting HTTP plugin...")
+
+	defer p.PluginHelper.TrackTime(j, time.Now(), "HTTP plugin completed in %s.")
+
+	j.Debugf("Performing %s %s", p.method, p.url)
+
+	response, err := p.fetch()
+
+	if err != nil {
+		j.SetFlowError(p.flowTag, map[string]interface{}{"error": err.Error()})
+		j.ReportError(err)
+		return
+	}
+
+	values, err := p.values(response)
+
+	if err != nil {
+		j.ReportError(err)
+		return
+	}
+
+	if p.flow != nil {
+		err = p.applyPatch(j, values)
+	} else {
+		err = p.applyExpression(j, values)
+	}
+
+	if err != nil {
+		j.ReportError(err)
+	}
+}
@@ -0,0 +1,236 @@
+package plugin
+
+import (
+	"encoding/json"
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/functions"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newTestHTTPPlugin(url string) *HTTPPlugin {
+	return &HTTPPlugin{
+		url:     url,
+		method:  "GET",
+		headers: map[string]string{},
+		client:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func TestHTTPFetchSendsMethodHeadersAndBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("Expected a POST request, got %s", r.Method)
+		}
+
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected Content-Type header %q", r.Header.Get("Content-Type"))
+		}
+
+		if r.Header.Get("X-Custom") != "value" {
+			t.Errorf("Unexpected X-Custom header %q", r.Header.Get("X-Custom"))
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+
+		if string(body) != `{"query":"open"}` {
+			t.Errorf("Unexpected body %q", body)
+		}
+
+		w.Write([]byte(`{"open": 12}`))
+	}))
+
+	defer server.Close()
+
+	p := newTestHTTPPlugin(server.URL)
+	p.method = "POST"
+	p.headers["Content-Type"] = "application/json"
+	p.headers["X-Custom"] = "value"
+	p.body = `{"query":"open"}`
+
+	response, err := p.fetch()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(response, map[string]interface{}{"open": 12.0}) {
+		t.Errorf("Unexpected response %#v", response)
+	}
+}
+
+func TestHTTPFetchSendsBasicAuthentication(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+
+		if !ok || username != "user" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Write([]byte(`true`))
+	}))
+
+	defer server.Close()
+
+	p := newTestHTTPPlugin(server.URL)
+	p.username = "user"
+	p.password = "secret"
+
+	if _, err := p.fetch(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHTTPFetchSendsBearerToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer abc123" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Write([]byte(`true`))
+	}))
+
+	defer server.Close()
+
+	p := newTestHTTPPlugin(server.URL)
+	p.token = "abc123"
+
+	if _, err := p.fetch(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestHTTPFetchTimesOut(t *testing.T) {
+	done := make(chan bool)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+		}
+	}))
+
+	defer server.Close()
+	defer close(done)
+
+	p := newTestHTTPPlugin(server.URL)
+	p.client.Timeout = 50 * time.Millisecond
+
+	start := time.Now()
+
+	if _, err := p.fetch(); err == nil {
+		t.Fatal("Expected the request to time out")
+	}
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("The request took %s to time out", elapsed)
+	}
+}
+
+func TestHTTPFetchReportsNon2xxResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"error": "down"}`))
+	}))
+
+	defer server.Close()
+
+	_, err := newTestHTTPPlugin(server.URL).fetch()
+
+	httpErr, ok := err.(*HTTPError)
+
+	if !ok {
+		t.Fatalf("Expected an *HTTPError, got %#v", err)
+	}
+
+	if httpErr.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Unexpected status code %d", httpErr.StatusCode)
+	}
+}
+
+func TestHTTPFetchRejectsInvalidJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html></html>`))
+	}))
+
+	defer server.Close()
+
+	if _, err := newTestHTTPPlugin(server.URL).fetch(); err == nil {
+		t.Fatal("Expected an error for a response that is not JSON")
+	}
+}
+
+func TestHTTPValuesAppliesSelectors(t *testing.T) {
+	p := newTestHTTPPlugin("")
+	p.extract = map[string]*jsonPath{}
+
+	for name, selector := range map[string]string{"open": "$.tickets.open", "history": "$.tickets.daily[*].count"} {
+		path, err := compileJSONPath(selector)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		p.extract[name] = path
+	}
+
+	var response interface{}
+
+	json.Unmarshal([]byte(`{"tickets": {"open": 3, "daily": [{"count": 1}, {"count": 2}]}}`), &response)
+
+	values, err := p.values(response)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values["open"] != 3.0 {
+		t.Errorf("Unexpected value for `open`: %#v", values["open"])
+	}
+
+	if !reflect.DeepEqual(values["history"], []interface{}{1.0, 2.0}) {
+		t.Errorf("Unexpected value for `history`: %#v", values["history"])
+	}
+
+	if !reflect.DeepEqual(values["#"], response) {
+		t.Errorf("Expected $$# to be the entire response, got %#v", values["#"])
+	}
+}
+
+func TestHTTPExpressionDoesNotEvaluateResponseData(t *testing.T) {
+	var expression interface{}
+
+	json.Unmarshal([]byte(`{"value": "$$open", "total": {"$add": {"left": "$$count", "right": 1}}}`), &expression)
+
+	values := map[string]interface{}{
+		"open":  map[string]interface{}{"$set": map[string]interface{}{"key": "k", "value": 1}},
+		"count": 2.0,
+	}
+
+	context := &aggregations.Context{}
+
+	for name, value := range values {
+		context.SetVariable(name, value)
+	}
+
+	result, err := functions.Parse(context, substitutePlaceholders(expression, values))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"value": map[string]interface{}{"$set": map[string]interface{}{"key": "k", "value": 1}},
+		"total": 3.0,
+	}
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %#v, got %#v", expected, result)
+	}
+}
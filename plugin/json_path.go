@@ -0,0 +1,199 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Type jsonPathStep is one component of a compiled JSON path. A step selects either
+// a property of an object (key), an element of an array (index), or every element
+// of an array or object (wildcard).
+type jsonPathStep struct {
+	key      string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// Type jsonPath is a compiled JSONPath-style selector.
+//
+// The supported syntax is a subset of JSONPath: `$` denotes the root of the document,
+// `.name` or `['name']` select a property, `[n]` selects an element of an array (negative
+// indices count from the end), and `[*]` or `.*` select every element (the properties of
+// an object are selected in the alphabetical order of their names). Once a wildcard has
+// been applied, the following steps are applied to each of the selected elements, and
+// the result is an array.
+//
+// For example, given {"data": {"items": [{"value": 1}, {"value": 2}]}}, the selector
+// `$.data.items[*].value` returns [1, 2], and `$.data.items[-1].value` returns 2.
+type jsonPath struct {
+	source string
+	steps  []jsonPathStep
+}
+
+func compileJSONPath(source string) (*jsonPath, error) {
+	result := &jsonPath{source: source}
+
+	path := strings.TrimSpace(source)
+
+	if strings.HasPrefix(path, "$") {
+		path = path[1:]
+	}
+
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+
+			end := strings.IndexAny(path, ".[")
+
+			if end == -1 {
+				end = len(path)
+			}
+
+			name := path[:end]
+			path = path[end:]
+
+			if name == "" {
+				return nil, errors.New(fmt.Sprintf("Empty property name in JSON path `%s`", source))
+			}
+
+			if name == "*" {
+				result.steps = append(result.steps, jsonPathStep{wildcard: true})
+			} else {
+				result.steps = append(result.steps, jsonPathStep{key: name})
+			}
+
+		case '[':
+			end := strings.Index(path, "]")
+
+			if end == -1 {
+				return nil, errors.New(fmt.Sprintf("Unterminated bracket in JSON path `%s`", source))
+			}
+
+			selector := strings.TrimSpace(path[1:end])
+			path = path[end+1:]
+
+			switch {
+			case selector == "*":
+				result.steps = append(result.steps, jsonPathStep{wildcard: true})
+
+			case len(selector) >= 2 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0]:
+				result.steps = append(result.steps, jsonPathStep{key: selector[1 : len(selector)-1]})
+
+			default:
+				index, err := strconv.Atoi(selector)
+
+				if err != nil {
+					return nil, errors.New(fmt.Sprintf("Invalid array index `%s` in JSON path `%s`", selector, source))
+				}
+
+				result.steps = append(result.steps, jsonPathStep{index: index, isIndex: true})
+			}
+
+		default:
+			if len(result.steps) > 0 {
+				return nil, errors.New(fmt.Sprintf("Unexpected character `%c` in JSON path `%s`", path[0], source))
+			}
+
+			// Allow paths that omit the leading `$.`
+			path = "." + path
+		}
+	}
+
+	return result, nil
+}
+
+// Evaluate applies the path to a document decoded with encoding/json.
+func (p *jsonPath) Evaluate(document interface{}) (interface{}, error) {
+	return p.evaluate(document, p.steps)
+}
+
+func (p *jsonPath) evaluate(value interface{}, steps []jsonPathStep) (interface{}, error) {
+	if len(steps) == 0 {
+		return value, nil
+	}
+
+	step := steps[0]
+
+	switch {
+	case step.wildcard:
+		result := []interface{}{}
+
+		switch v := value.(type) {
+		case []interface{}:
+			for _, element := range v {
+				r, err := p.evaluate(element, steps[1:])
+
+				if err != nil {
+					return nil, err
+				}
+
+				result = append(result, r)
+			}
+
+		case map[string]interface{}:
+			// Visit the properties in the order of their names, so that the result
+			// doesn't change from one run to the next
+			keys := []string{}
+
+			for key := range v {
+				keys = append(keys, key)
+			}
+
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				r, err := p.evaluate(v[key], steps[1:])
+
+				if err != nil {
+					return nil, err
+				}
+
+				result = append(result, r)
+			}
+
+		default:
+			return nil, errors.New(fmt.Sprintf("JSON path `%s`: cannot apply a wildcard to %#v", p.source, value))
+		}
+
+		return result, nil
+
+	case step.isIndex:
+		array, ok := value.([]interface{})
+
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("JSON path `%s`: %#v is not an array", p.source, value))
+		}
+
+		index := step.index
+
+		if index < 0 {
+			index += len(array)
+		}
+
+		if index < 0 || index >= len(array) {
+			return nil, errors.New(fmt.Sprintf("JSON path `%s`: index %d is out of range", p.source, step.index))
+		}
+
+		return p.evaluate(array[index], steps[1:])
+
+	default:
+		object, ok := value.(map[string]interface{})
+
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("JSON path `%s`: %#v is not an object", p.source, value))
+		}
+
+		property, ok := object[step.key]
+
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("JSON path `%s`: property `%s` not found", p.source, step.key))
+		}
+
+		return p.evaluate(property, steps[1:])
+	}
+}
@@ -0,0 +1,102 @@
+package plugin
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+const jsonPathTestDocument = `{
+	"data": {
+		"items": [{"value": 1}, {"value": 2}, {"value": 3}],
+		"totals": {"b": 20, "a": 10, "c": 30},
+		"odd key": "spaces"
+	},
+	"count": 3
+}`
+
+func TestJSONPathSelectors(t *testing.T) {
+	var document interface{}
+
+	if err := json.Unmarshal([]byte(jsonPathTestDocument), &document); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		selector string
+		expected interface{}
+	}{
+		{"$", document},
+		{"$.count", 3.0},
+		{"count", 3.0},
+		{"$.data.items[0].value", 1.0},
+		{"$.data.items[-1].value", 3.0},
+		{"$['data']['odd key']", "spaces"},
+		{`$.data["odd key"]`, "spaces"},
+		{"$.data.items[*].value", []interface{}{1.0, 2.0, 3.0}},
+		{"$.data.items.*.value", []interface{}{1.0, 2.0, 3.0}},
+		{"$.data.totals.*", []interface{}{10.0, 20.0, 30.0}},
+		{"$.data.totals[*]", []interface{}{10.0, 20.0, 30.0}},
+	}
+
+	for _, test := range tests {
+		path, err := compileJSONPath(test.selector)
+
+		if err != nil {
+			t.Errorf("%s: %s", test.selector, err)
+			continue
+		}
+
+		result, err := path.Evaluate(document)
+
+		if err != nil {
+			t.Errorf("%s: %s", test.selector, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("%s: expected %#v, got %#v", test.selector, test.expected, result)
+		}
+	}
+}
+
+func TestJSONPathEvaluationErrors(t *testing.T) {
+	var document interface{}
+
+	if err := json.Unmarshal([]byte(jsonPathTestDocument), &document); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, selector := range []string{
+		"$.missing",
+		"$.data.items[3]",
+		"$.data.items[-4]",
+		"$.count[0]",
+		"$.count.value",
+		"$.count.*",
+	} {
+		path, err := compileJSONPath(selector)
+
+		if err != nil {
+			t.Errorf("%s: %s", selector, err)
+			continue
+		}
+
+		if _, err := path.Evaluate(document); err == nil {
+			t.Errorf("%s: expected an error", selector)
+		}
+	}
+}
+
+func TestJSONPathSyntaxErrors(t *testing.T) {
+	for _, selector := range []string{
+		"$.data.",
+		"$.data[0",
+		"$.data[abc]",
+		"$.data[0]x",
+	} {
+		if _, err := compileJSONPath(selector); err == nil {
+			t.Errorf("%s: expected a syntax error", selector)
+		}
+	}
+}
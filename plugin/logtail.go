@@ -0,0 +1,776 @@
+package plugin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/telemetryapp/gotelemetry_agent/agent/aggregations"
+	"github.com/telemetryapp/gotelemetry_agent/agent/config"
+	"github.com/telemetryapp/gotelemetry_agent/agent/job"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// init() registers this plugin with the Plugin Manager.
+func init() {
+	job.RegisterPlugin("com.telemetryapp.logtail", LogTailPluginFactory)
+}
+
+// Func LogTailPluginFactory generates a blank plugin instance of the
+// `com.telemetryapp.logtail` plugin
+func LogTailPluginFactory() job.PluginInstance {
+	return &LogTailPlugin{
+		PluginHelper: job.NewPluginHelper(),
+	}
+}
+
+// Struct LogTailPlugin follows a log file as it grows, matches each new line against
+// a set of regular expressions, and turns the matches into data points that are
+// appended to series in the data layer.
+//
+// For configuration parameters, see Init()
+type LogTailPlugin struct {
+	*job.PluginHelper
+	path          string
+	statePath     string
+	fromBeginning bool
+	rules         []*logTailRule
+	file          *os.File
+	fileInfo      os.FileInfo
+	offset        int64
+	partial       []byte
+}
+
+// Struct logTailRule holds a single rule and the buckets it has accumulated but not
+// yet written to its series.
+type logTailRule struct {
+	name        string
+	regex       *regexp.Regexp
+	series      string
+	extract     bool
+	group       int
+	interval    int64
+	aggregate   string
+	buckets     map[int64][]float64
+	pending     int
+	dropped     int
+	lastFlushed int64
+	hasFlushed  bool
+}
+
+// Struct logTailState is persisted to disk so that the plugin can resume reading
+// from the right place after the agent is restarted.
+type logTailState struct {
+	Offset int64  `json:"offset"`
+	Head   []byte `json:"head"`
+}
+
+// The number of bytes at the beginning of the file that are used to recognize it
+// after a restart.
+const logTailHeadSize = 256
+
+// The maximum number of matches that a rule keeps while they can't be written to the
+// data layer. Once it is reached, the oldest buckets are discarded to make room.
+const logTailMaxPendingMatches = 100000
+
+// Init initializes the plugin.
+//
+// The required configuration parameters are:
+//
+// - path                         The path to the log file
+//
+// - refresh                      The number of seconds between subsequent checks for new lines. Default: 5
+//
+//   - state_path                   The path to the file in which the plugin remembers how much of the log it has read.
+//     Default: a file in the directory of the data layer's database, named after the log file
+//
+//   - from_beginning               Whether to read the entire file the first time it is seen, rather than only the lines
+//     that are appended to it from then on. Default: false
+//
+// - rules                        A list of rules, each of which has these properties:
+//
+//	name                       A name used in the logs
+//
+//	pattern                    A regular expression that lines must match
+//
+//	series                     The series to which data points are appended
+//
+//	type                       Either `count`, which counts the matching lines, or `extract`, which extracts a
+//	                           number from each matching line. Default: count
+//
+//	group                      For `extract` rules, the index or name of the capture group that contains the
+//	                           number. Default: 1
+//
+//	interval                   The granularity, in seconds, at which data points are written. Matches are grouped
+//	                           into buckets of this length, and one data point is written for each bucket once it is
+//	                           complete. Count rules write a zero for buckets without matches. Use 0 to write one data
+//	                           point for each matching line. Default: 60
+//
+//	aggregate                  For `extract` rules with an interval, how the values in a bucket are combined: `sum`,
+//	                           `avg`, `min`, `max`, or `last`. Default: avg
+//
+// The plugin notices when the log file is truncated, in which case it starts reading
+// again from the beginning, and when it is rotated, in which case it finishes reading
+// the old file before switching to the new one.
+//
+// The plugin requires the data layer, so the `data.path` property must be set in the
+// configuration file.
+//
+// The read offset is saved after data points have been written; if they can't be
+// written, they are tried again on the next run. While that keeps failing, each rule
+// holds on to at most 100,000 matches, after which the oldest buckets are discarded.
+// Matches in buckets that are not yet complete when the agent stops are lost.
+//
+// For example:
+//
+//	jobs:
+//	  - id: Web server
+//	    plugin: com.telemetryapp.logtail
+//	    config:
+//	      path: /var/log/nginx/access.log
+//	      refresh: 10
+//	      rules:
+//	        - name: Requests
+//	          pattern: '"(GET|POST|PUT|DELETE) '
+//	          series: http_requests
+//	        - name: Errors
+//	          pattern: '" 5\d\d '
+//	          series: http_errors
+//	        - name: Response time
+//	          pattern: 'rt=(?P<time>[\d.]+)'
+//	          type: extract
+//	          group: time
+//	          aggregate: max
+//	          series: http_response_time
+func (p *LogTailPlugin) Init(job *job.Job) error {
+	var ok bool
+
+	c := job.Config()
+
+	p.path, ok = c["path"].(string)
+
+	if !ok {
+		return errors.New("The required `path` property (`string`) is either missing or of the wrong type.")
+	}
+
+	dataPath := aggregations.DataPath()
+
+	if dataPath == "" {
+		return errors.New("The log tail plugin requires the data layer. Set the `data.path` property in the configuration file to enable it.")
+	}
+
+	p.statePath, ok = c["state_path"].(string)
+
+	if !ok {
+		p.statePath = defaultLogTailStatePath(dataPath, p.path)
+	}
+
+	p.fromBeginning, _ = c["from_beginning"].(bool)
+
+	rules, ok := config.MapFromYaml(c["rules"]).([]interface{})
+
+	if !ok || len(rules) == 0 {
+		return errors.New("The required `rules` property must be a non-empty array.")
+	}
+
+	for index, rule := range rules {
+		rule, ok := rule.(map[string]interface{})
+
+		if !ok {
+			return errors.New(fmt.Sprintf("Rule %d must be an object.", index))
+		}
+
+		r, err := newLogTailRule(rule)
+
+		if err != nil {
+			return errors.New(fmt.Sprintf("Rule %d: %s", index, err))
+		}
+
+		p.rules = append(p.rules, r)
+	}
+
+	refresh := 5
+
+	if r, ok := c["refresh"].(int); ok {
+		if r <= 0 {
+			return errors.New("Invalid refresh interval")
+		}
+
+		refresh = r
+	}
+
+	p.PluginHelper.AddTaskWithClosure(p.performAllTasks, time.Duration(refresh)*time.Second)
+
+	return nil
+}
+
+// defaultLogTailStatePath returns the path of the state file of a log that doesn't
+// specify one. It is kept next to the data layer's database, rather than next to the
+// log, whose directory is often not writable by the agent; a checksum of the log's
+// absolute path tells apart logs that have the same name.
+func defaultLogTailStatePath(dataPath, logPath string) string {
+	if abs, err := filepath.Abs(logPath); err == nil {
+		logPath = abs
+	}
+
+	name := fmt.Sprintf("%s.%08x.telemetry_offset", filepath.Base(logPath), crc32.ChecksumIEEE([]byte(logPath)))
+
+	return filepath.Join(filepath.Dir(dataPath), name)
+}
+
+func newLogTailRule(c map[string]interface{}) (*logTailRule, error) {
+	var ok bool
+
+	result := &logTailRule{
+		group:     1,
+		interval:  60,
+		aggregate: "avg",
+		buckets:   map[int64][]float64{},
+	}
+
+	pattern, ok := c["pattern"].(string)
+
+	if !ok {
+		return nil, errors.New("The required `pattern` property (`string`) is either missing or of the wrong type.")
+	}
+
+	rx, err := regexp.Compile(pattern)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result.regex = rx
+
+	result.name, ok = c["name"].(string)
+
+	if !ok {
+		result.name = pattern
+	}
+
+	result.series, ok = c["series"].(string)
+
+	if !ok {
+		return nil, errors.New("The required `series` property (`string`) is either missing or of the wrong type.")
+	}
+
+	switch c["type"] {
+	case nil, "count":
+		result.extract = false
+
+	case "extract":
+		result.extract = true
+
+	default:
+		return nil, errors.New(fmt.Sprintf("Unknown rule type `%v`", c["type"]))
+	}
+
+	switch group := c["group"].(type) {
+	case nil:
+		// Use the default
+
+	case int:
+		result.group = group
+
+	case string:
+		result.group = -1
+
+		for index, name := range rx.SubexpNames() {
+			if name == group {
+				result.group = index
+			}
+		}
+
+		if result.group == -1 {
+			return nil, errors.New("The pattern does not contain a capture group called `" + group + "`")
+		}
+
+	default:
+		return nil, errors.New("The `group` property must be either a number or the name of a capture group.")
+	}
+
+	if result.extract && (result.group < 1 || result.group > rx.NumSubexp()) {
+		return nil, errors.New(fmt.Sprintf("The pattern does not contain a capture group with index %d", result.group))
+	}
+
+	if interval, ok := c["interval"].(int); ok {
+		if interval < 0 {
+			return nil, errors.New("Invalid interval")
+		}
+
+		result.interval = int64(interval)
+	}
+
+	if aggregate, ok := c["aggregate"].(string); ok {
+		switch aggregate {
+		case "sum", "avg", "min", "max", "last":
+			result.aggregate = aggregate
+
+		default:
+			return nil, errors.New("Unknown aggregate `" + aggregate + "`")
+		}
+	}
+
+	return result, nil
+}
+
+// match records a line if it matches the rule. It returns an error only if a value
+// that should be extracted isn't a number.
+func (r *logTailRule) match(line []byte, now time.Time) error {
+	matches := r.regex.FindSubmatch(line)
+
+	if matches == nil {
+		return nil
+	}
+
+	value := 1.0
+
+	if r.extract {
+		v, err := strconv.ParseFloat(string(matches[r.group]), 64)
+
+		if err != nil {
+			return errors.New(fmt.Sprintf("Rule `%s`: unable to parse `%s` as a number", r.name, matches[r.group]))
+		}
+
+		value = v
+	}
+
+	bucket := now.Unix()
+
+	if r.interval > 0 {
+		bucket = bucket / r.interval * r.interval
+	}
+
+	r.buckets[bucket] = append(r.buckets[bucket], value)
+	r.pending += 1
+
+	for r.pending > logTailMaxPendingMatches {
+		r.discardOldestBucket()
+	}
+
+	return nil
+}
+
+// discardOldestBucket drops the oldest bucket of the rule, which keeps the matches that
+// can't be written to the data layer from taking up an unbounded amount of memory.
+func (r *logTailRule) discardOldestBucket() {
+	oldest := int64(math.MaxInt64)
+
+	for bucket := range r.buckets {
+		if bucket < oldest {
+			oldest = bucket
+		}
+	}
+
+	r.pending -= len(r.buckets[oldest])
+	r.dropped += len(r.buckets[oldest])
+
+	delete(r.buckets, oldest)
+}
+
+// Struct logTailFlush describes the buckets that a call to flush has written to the
+// rule's series. They are only removed from the rule by commit, once the transaction in
+// which they were written has been committed, so that they can be written again if it
+// fails.
+type logTailFlush struct {
+	buckets     []int64
+	lastFlushed int64
+	count       int
+}
+
+// flush writes the values of all the buckets that are complete to the rule's series.
+func (r *logTailRule) flush(context *aggregations.Context, now time.Time) (*logTailFlush, error) {
+	series, err := aggregations.GetSeries(context, r.series)
+
+	if err != nil {
+		return nil, err
+	}
+
+	current := now.Unix()
+
+	if r.interval > 0 {
+		current = current / r.interval * r.interval
+	}
+
+	result := &logTailFlush{lastFlushed: r.lastFlushed}
+
+	if !r.hasFlushed {
+		// Start from the oldest bucket we have, or from the current one if we have none.
+
+		result.lastFlushed = current
+
+		for bucket := range r.buckets {
+			if bucket < result.lastFlushed {
+				result.lastFlushed = bucket
+			}
+		}
+
+		if r.interval > 0 {
+			result.lastFlushed -= r.interval
+		}
+	}
+
+	if r.interval == 0 {
+		for bucket, values := range r.buckets {
+			ts := time.Unix(bucket, 0)
+
+			for _, value := range values {
+				if err := series.Push(&ts, value); err != nil {
+					return nil, err
+				}
+
+				result.count += 1
+			}
+
+			result.buckets = append(result.buckets, bucket)
+		}
+
+		return result, nil
+	}
+
+	for bucket := result.lastFlushed + r.interval; bucket < current; bucket += r.interval {
+		values, ok := r.buckets[bucket]
+
+		if !ok && r.extract {
+			continue
+		}
+
+		ts := time.Unix(bucket, 0)
+
+		if err := series.Push(&ts, r.combine(values)); err != nil {
+			return nil, err
+		}
+
+		result.buckets = append(result.buckets, bucket)
+		result.lastFlushed = bucket
+		result.count += 1
+	}
+
+	if current-r.interval > result.lastFlushed {
+		result.lastFlushed = current - r.interval
+	}
+
+	return result, nil
+}
+
+// commit removes the buckets written by flush from the rule.
+func (r *logTailRule) commit(f *logTailFlush) {
+	for _, bucket := range f.buckets {
+		r.pending -= len(r.buckets[bucket])
+		delete(r.buckets, bucket)
+	}
+
+	r.lastFlushed = f.lastFlushed
+	r.hasFlushed = true
+}
+
+func (r *logTailRule) combine(values []float64) float64 {
+	if !r.extract {
+		return float64(len(values))
+	}
+
+	switch r.aggregate {
+	case "sum", "avg":
+		sum := 0.0
+
+		for _, value := range values {
+			sum += value
+		}
+
+		if r.aggregate == "avg" {
+			return sum / float64(len(values))
+		}
+
+		return sum
+
+	case "min":
+		result := math.Inf(1)
+
+		for _, value := range values {
+			result = math.Min(result, value)
+		}
+
+		return result
+
+	case "max":
+		result := math.Inf(-1)
+
+		for _, value := range values {
+			result = math.Max(result, value)
+		}
+
+		return result
+	}
+
+	return values[len(values)-1]
+}
+
+// loadState returns the offset at which reading should start for the file that is
+// currently open.
+func (p *LogTailPlugin) loadState() int64 {
+	defaultOffset := p.fileInfo.Size()
+
+	if p.fromBeginning {
+		defaultOffset = 0
+	}
+
+	source, err := ioutil.ReadFile(p.statePath)
+
+	if err != nil {
+		return defaultOffset
+	}
+
+	state := logTailState{}
+
+	if err := json.Unmarshal(source, &state); err != nil {
+		return defaultOffset
+	}
+
+	head, err := p.readHead()
+
+	if err != nil || len(head) < len(state.Head) || !bytes.Equal(head[:len(state.Head)], state.Head) {
+		// The file was replaced while we weren't looking
+		return 0
+	}
+
+	if state.Offset > p.fileInfo.Size() {
+		// The file was truncated while we weren't looking
+		return 0
+	}
+
+	return state.Offset
+}
+
+func (p *LogTailPlugin) saveState() error {
+	head, err := p.readHead()
+
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(logTailState{Offset: p.offset, Head: head})
+
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(p.statePath, data, 0644)
+}
+
+func (p *LogTailPlugin) readHead() ([]byte, error) {
+	head := make([]byte, logTailHeadSize)
+
+	n, err := p.file.ReadAt(head, 0)
+
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return head[:n], nil
+}
+
+func (p *LogTailPlugin) open(resume bool) error {
+	f, err := os.Open(p.path)
+
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	p.file = f
+	p.fileInfo = info
+	p.partial = nil
+
+	if resume {
+		p.offset = p.loadState()
+	} else {
+		p.offset = 0
+	}
+
+	return nil
+}
+
+// readLines returns the complete lines that have been appended to the file since
+// the last time it was called, handling truncation and rotation.
+func (p *LogTailPlugin) readLines(j *job.Job) ([][]byte, error) {
+	if p.file == nil {
+		if err := p.open(true); err != nil {
+			return nil, err
+		}
+	}
+
+	lines, err := p.readFrom(p.file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(p.path)
+
+	if err != nil {
+		if os.IsNotExist(err) {
+			// The file was rotated, and its replacement hasn't been created yet.
+			return lines, nil
+		}
+
+		return nil, err
+	}
+
+	if !os.SameFile(info, p.fileInfo) {
+		j.Logf("%s was rotated; switching to the new file", p.path)
+
+		p.file.Close()
+
+		if err := p.open(false); err != nil {
+			return nil, err
+		}
+
+		newLines, err := p.readFrom(p.file)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return append(lines, newLines...), nil
+	}
+
+	if info.Size() < p.offset {
+		j.Logf("%s was truncated; reading from the beginning", p.path)
+
+		p.offset = 0
+		p.partial = nil
+
+		return p.readFrom(p.file)
+	}
+
+	return lines, nil
+}
+
+func (p *LogTailPlugin) readFrom(f *os.File) ([][]byte, error) {
+	if _, err := f.Seek(p.offset+int64(len(p.partial)), 0); err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadAll(f)
+
+	if err != nil {
+		return nil, err
+	}
+
+	data = append(p.partial, data...)
+
+	lines := [][]byte{}
+
+	for {
+		index := bytes.IndexByte(data, '\n')
+
+		if index == -1 {
+			break
+		}
+
+		lines = append(lines, bytes.TrimRight(data[:index], "\r"))
+
+		p.offset += int64(index + 1)
+		data = data[index+1:]
+	}
+
+	p.partial = append([]byte{}, data...)
+
+	return lines, nil
+}
+
+func (p *LogTailPlugin) performAllTasks(j *job.Job) {
+	j.Debugf("Starting log tail plugin...")
+
+	defer p.PluginHelper.TrackTime(j, time.Now(), "Log tail plugin completed in %s.")
+
+	lines, err := p.readLines(j)
+
+	if err != nil {
+		j.ReportError(err)
+		return
+	}
+
+	now := time.Now()
+
+	for _, line := range lines {
+		for _, rule := range p.rules {
+			if err := rule.match(line, now); err != nil {
+				j.ReportError(err)
+			}
+		}
+	}
+
+	for _, rule := range p.rules {
+		if rule.dropped > 0 {
+			j.ReportError(errors.New(fmt.Sprintf("Rule `%s`: discarded %d matches that could not be written to the data layer", rule.name, rule.dropped)))
+			rule.dropped = 0
+		}
+	}
+
+	context, err := aggregations.GetContext()
+
+	if err != nil {
+		j.ReportError(err)
+		return
+	}
+
+	if err := context.Begin(); err != nil {
+		context.Close()
+		j.ReportError(err)
+		return
+	}
+
+	flushes := []*logTailFlush{}
+
+	for _, rule := range p.rules {
+		f, err := rule.flush(context, now)
+
+		if err != nil {
+			context.SetError()
+			context.Close()
+			j.ReportError(err)
+			return
+		}
+
+		flushes = append(flushes, f)
+	}
+
+	// The matches stay in their buckets, and the offset isn't saved, until the data
+	// points have been committed; otherwise, they would be lost if the commit failed.
+
+	err = context.Commit()
+
+	context.Close()
+
+	if err != nil {
+		j.ReportError(err)
+		return
+	}
+
+	for index, rule := range p.rules {
+		rule.commit(flushes[index])
+
+		if flushes[index].count > 0 {
+			j.Debugf("Rule `%s` wrote %d data points to series `%s`", rule.name, flushes[index].count, rule.series)
+		}
+	}
+
+	if err := p.saveState(); err != nil {
+		j.ReportError(err)
+	}
+}
@@ -0,0 +1,259 @@
+package plugin
+
+import (
+	"github.com/telemetryapp/gotelemetry_agent/agent/job"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func newTestLogTail(t *testing.T) (*LogTailPlugin, string, func()) {
+	dir, err := ioutil.TempDir("", "logtail")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &LogTailPlugin{
+		path:      filepath.Join(dir, "test.log"),
+		statePath: filepath.Join(dir, "test.log.state"),
+	}
+
+	return p, dir, func() {
+		if p.file != nil {
+			p.file.Close()
+		}
+
+		os.RemoveAll(dir)
+	}
+}
+
+func writeTestLog(t *testing.T, path string, data string, flag int) {
+	f, err := os.OpenFile(path, flag|os.O_WRONLY|os.O_CREATE, 0644)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer f.Close()
+
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readTestLines(t *testing.T, p *LogTailPlugin) []string {
+	lines, err := p.readLines(&job.Job{})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := []string{}
+
+	for _, line := range lines {
+		result = append(result, string(line))
+	}
+
+	return result
+}
+
+func expectTestLines(t *testing.T, p *LogTailPlugin, expected ...string) {
+	if expected == nil {
+		expected = []string{}
+	}
+
+	if lines := readTestLines(t, p); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Expected lines %#v, got %#v", expected, lines)
+	}
+}
+
+func TestLogTailReadsFromTheEndOfAnExistingFile(t *testing.T) {
+	p, _, cleanup := newTestLogTail(t)
+	defer cleanup()
+
+	writeTestLog(t, p.path, "old\n", os.O_TRUNC)
+
+	expectTestLines(t, p)
+
+	writeTestLog(t, p.path, "new\n", os.O_APPEND)
+
+	expectTestLines(t, p, "new")
+}
+
+func TestLogTailReadsFromTheBeginningIfRequested(t *testing.T) {
+	p, _, cleanup := newTestLogTail(t)
+	defer cleanup()
+
+	p.fromBeginning = true
+
+	writeTestLog(t, p.path, "one\r\ntwo\n", os.O_TRUNC)
+
+	expectTestLines(t, p, "one", "two")
+}
+
+func TestLogTailKeepsPartialLines(t *testing.T) {
+	p, _, cleanup := newTestLogTail(t)
+	defer cleanup()
+
+	p.fromBeginning = true
+
+	writeTestLog(t, p.path, "first\nsec", os.O_TRUNC)
+
+	expectTestLines(t, p, "first")
+
+	if p.offset != int64(len("first\n")) {
+		t.Errorf("The offset should not include the partial line, got %d", p.offset)
+	}
+
+	writeTestLog(t, p.path, "ond\nthi", os.O_APPEND)
+
+	expectTestLines(t, p, "second")
+
+	writeTestLog(t, p.path, "rd\n", os.O_APPEND)
+
+	expectTestLines(t, p, "third")
+}
+
+func TestLogTailRestartsAfterTruncation(t *testing.T) {
+	p, _, cleanup := newTestLogTail(t)
+	defer cleanup()
+
+	p.fromBeginning = true
+
+	writeTestLog(t, p.path, "a long first line\nanother line\n", os.O_TRUNC)
+
+	expectTestLines(t, p, "a long first line", "another line")
+
+	writeTestLog(t, p.path, "short\n", os.O_TRUNC)
+
+	expectTestLines(t, p, "short")
+
+	writeTestLog(t, p.path, "more\n", os.O_APPEND)
+
+	expectTestLines(t, p, "more")
+}
+
+func TestLogTailFollowsRotation(t *testing.T) {
+	p, dir, cleanup := newTestLogTail(t)
+	defer cleanup()
+
+	p.fromBeginning = true
+
+	writeTestLog(t, p.path, "before\n", os.O_TRUNC)
+
+	expectTestLines(t, p, "before")
+
+	rotated := filepath.Join(dir, "test.log.1")
+
+	if err := os.Rename(p.path, rotated); err != nil {
+		t.Fatal(err)
+	}
+
+	// Lines written to the old file after it was moved, but before the new one exists
+	writeTestLog(t, rotated, "late\n", os.O_APPEND)
+
+	expectTestLines(t, p, "late")
+
+	writeTestLog(t, rotated, "last\n", os.O_APPEND)
+	writeTestLog(t, p.path, "new file\n", os.O_TRUNC)
+
+	expectTestLines(t, p, "last", "new file")
+
+	writeTestLog(t, p.path, "after\n", os.O_APPEND)
+
+	expectTestLines(t, p, "after")
+}
+
+func TestLogTailResumesFromTheSavedState(t *testing.T) {
+	p, _, cleanup := newTestLogTail(t)
+	defer cleanup()
+
+	writeTestLog(t, p.path, "one\n", os.O_TRUNC)
+
+	expectTestLines(t, p)
+
+	writeTestLog(t, p.path, "two\n", os.O_APPEND)
+
+	expectTestLines(t, p, "two")
+
+	if err := p.saveState(); err != nil {
+		t.Fatal(err)
+	}
+
+	p.file.Close()
+
+	writeTestLog(t, p.path, "three\n", os.O_APPEND)
+
+	resumed := &LogTailPlugin{path: p.path, statePath: p.statePath}
+	defer func() { resumed.file.Close() }()
+
+	expectTestLines(t, resumed, "three")
+}
+
+func TestLogTailIgnoresTheStateOfAReplacedFile(t *testing.T) {
+	p, _, cleanup := newTestLogTail(t)
+	defer cleanup()
+
+	writeTestLog(t, p.path, "original contents\n", os.O_TRUNC)
+
+	expectTestLines(t, p)
+
+	if err := p.saveState(); err != nil {
+		t.Fatal(err)
+	}
+
+	p.file.Close()
+
+	writeTestLog(t, p.path, "replacement\nfile\n", os.O_TRUNC)
+
+	resumed := &LogTailPlugin{path: p.path, statePath: p.statePath}
+	defer func() { resumed.file.Close() }()
+
+	expectTestLines(t, resumed, "replacement", "file")
+}
+
+func TestLogTailRuleCapsPendingMatches(t *testing.T) {
+	r := &logTailRule{
+		regex:    regexp.MustCompile("x"),
+		interval: 60,
+		buckets:  map[int64][]float64{},
+	}
+
+	start := time.Unix(6000, 0)
+
+	for index := 0; index < logTailMaxPendingMatches; index++ {
+		if err := r.match([]byte("x"), start); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := r.match([]byte("x"), start.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	if r.pending != 1 || r.dropped != logTailMaxPendingMatches {
+		t.Errorf("Expected 1 pending and %d dropped matches, got %d and %d", logTailMaxPendingMatches, r.pending, r.dropped)
+	}
+
+	if _, ok := r.buckets[6000]; ok {
+		t.Error("The oldest bucket should have been discarded")
+	}
+}
+
+func TestLogTailDefaultStatePath(t *testing.T) {
+	first := defaultLogTailStatePath("/var/lib/agent/data.db", "/var/log/a/access.log")
+	second := defaultLogTailStatePath("/var/lib/agent/data.db", "/var/log/b/access.log")
+
+	if filepath.Dir(first) != "/var/lib/agent" {
+		t.Errorf("The state file should be next to the database, got %s", first)
+	}
+
+	if first == second {
+		t.Errorf("Logs with the same name in different directories share the state file %s", first)
+	}
+}
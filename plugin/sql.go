@@ -1,8 +1,10 @@
 package plugin
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/evanphx/json-patch"
 	"github.com/telemetryapp/gotelemetry"
@@ -33,20 +35,39 @@ type SQLPlugin struct {
 	*job.PluginHelper
 	driverName     string
 	datasourceName string
-	query          string
-	patch          string
-	flowTag        string
-	variant        string
-	flow           *gotelemetry.Flow
+	queries        []*sqlQuery
+}
+
+// sqlTransactionOptions contains the options of the transaction inside which the
+// queries are executed for the drivers whose default isolation level does not
+// guarantee that all the queries see the same snapshot of the data. The SQL Server
+// driver does not support read-only transactions.
+var sqlTransactionOptions = map[string]*sql.TxOptions{
+	"postgres":  {Isolation: sql.LevelRepeatableRead, ReadOnly: true},
+	"mysql":     {Isolation: sql.LevelRepeatableRead, ReadOnly: true},
+	"mssql":     {Isolation: sql.LevelSnapshot},
+	"sqlserver": {Isolation: sql.LevelSnapshot},
+}
+
+// Struct sqlQuery holds a single query and the flow that its results are used to
+// populate.
+type sqlQuery struct {
+	query   string
+	patch   string
+	flowTag string
+	variant string
+	flow    *gotelemetry.Flow
 }
 
 // Function Init initializes the plugin.
 //
 // The required configuration parameters are:
 //
-// - driver                       The SQL driver to use
+// - driver                       The SQL driver to use. Run the agent with the `drivers` command
+//                                for a list of available drivers
 //
-// - datasource                   The datasource on which to operate
+// - datasource                   The datasource on which to operate. Its format depends on the driver,
+//                                and is validated when the job is initialized
 //
 // - query                        The query to be executed
 //
@@ -62,6 +83,17 @@ type SQLPlugin struct {
 // the number of the current row, and $$n as a placeholder for the value of column
 // n in the current row.
 //
+// Instead of `query`, `flow_tag`, `variant`, `template`, and `patch`, you can provide
+// a `queries` array, each of whose entries contains its own copy of those five
+// properties. All the queries are executed over a single connection inside the same
+// transaction, which is always rolled back at the end of the run. With the postgres and
+// mysql drivers, the transaction is read-only and uses the REPEATABLE READ isolation
+// level; with the mssql and sqlserver drivers, it uses the SNAPSHOT isolation level,
+// which requires the ALLOW_SNAPSHOT_ISOLATION database option. This guarantees that
+// all the queries see the same snapshot of the data, and therefore that the flows
+// populated by a job are consistent with each other. SQLite transactions are always
+// serializable; other drivers use their default isolation level.
+//
 // For example:
 //
 //   - id: Users with five or more sessions
@@ -79,30 +111,83 @@ type SQLPlugin struct {
 //         label: Frequent Users
 //         value_type: percent
 //         value: 100
+//
+// Or, with multiple queries:
+//
+//   - id: Sales
+//     plugin: com.telemetryapp.sql
+//     config:
+//       driver: postgres
+//       datasource: postgres://reports@warehouse/sales
+//       refresh: 300
+//       queries:
+//         - flow_tag: sales_today
+//           variant: value
+//           query: "select sum(total) from orders where created_at >= current_date"
+//           patch:
+//             - { "op": "replace" , "path": "/value", "value": $$0 }
+//         - flow_tag: orders_today
+//           variant: value
+//           query: "select count(*) from orders where created_at >= current_date"
+//           patch:
+//             - { "op": "replace" , "path": "/value", "value": $$0 }
 func (p *SQLPlugin) Init(job *job.Job) error {
-	var err error
+	var ok bool
 
 	c := job.Config()
 
-	p.driverName = c["driver"].(string)
-	p.datasourceName = c["datasource"].(string)
-	p.query = c["query"].(string)
-	p.flowTag = c["flow_tag"].(string)
-	p.variant = c["variant"].(string)
+	p.driverName, ok = c["driver"].(string)
 
-	patch, err := json.Marshal(config.MapFromYaml(c["patch"]))
+	if !ok {
+		return errors.New("The required `driver` property (`string`) is either missing or of the wrong type.")
+	}
 
-	if err != nil {
-		job.ReportError(err)
+	p.datasourceName, ok = c["datasource"].(string)
+
+	if !ok {
+		return errors.New("The required `datasource` property (`string`) is either missing or of the wrong type.")
+	}
+
+	if err := validateSQLDatasource(p.driverName, p.datasourceName); err != nil {
 		return err
 	}
 
-	p.patch = string(patch)
+	p.queries = []*sqlQuery{}
 
-	p.flow, err = job.GetOrCreateFlow(p.flowTag, p.variant, c["template"])
+	if queries, ok := c["queries"]; ok {
+		if _, ok := c["query"]; ok {
+			return errors.New("The `query` and `queries` properties cannot be used together.")
+		}
 
-	if err != nil {
-		return err
+		queryList, ok := config.MapFromYaml(queries).([]interface{})
+
+		if !ok || len(queryList) == 0 {
+			return errors.New("The `queries` property must be a non-empty array.")
+		}
+
+		for index, queryConfig := range queryList {
+			queryConfig, ok := queryConfig.(map[string]interface{})
+
+			if !ok {
+				return errors.New(fmt.Sprintf("Entry %d of the `queries` property must be an object.", index))
+			}
+
+			q, err := newSQLQuery(job, queryConfig)
+
+			if err != nil {
+				return errors.New(fmt.Sprintf("Entry %d of the `queries` property: %s", index, err))
+			}
+
+			p.queries = append(p.queries, q)
+		}
+	} else {
+		q, err := newSQLQuery(job, c)
+
+		if err != nil {
+			return err
+		}
+
+		p.queries = append(p.queries, q)
 	}
 
 	if refresh, ok := c["refresh"]; ok {
@@ -114,6 +199,50 @@ func (p *SQLPlugin) Init(job *job.Job) error {
 	return nil
 }
 
+// newSQLQuery creates a query based on a configuration map that contains the
+// `query`, `flow_tag`, `variant`, `template`, and `patch` properties.
+func newSQLQuery(job *job.Job, c map[string]interface{}) (*sqlQuery, error) {
+	var ok bool
+	var err error
+
+	result := &sqlQuery{}
+
+	result.query, ok = c["query"].(string)
+
+	if !ok {
+		return nil, errors.New("The required `query` property (`string`) is either missing or of the wrong type.")
+	}
+
+	result.flowTag, ok = c["flow_tag"].(string)
+
+	if !ok {
+		return nil, errors.New("The required `flow_tag` property (`string`) is either missing or of the wrong type.")
+	}
+
+	result.variant, ok = c["variant"].(string)
+
+	if !ok {
+		return nil, errors.New("The required `variant` property (`string`) is either missing or of the wrong type.")
+	}
+
+	patch, err := json.Marshal(config.MapFromYaml(c["patch"]))
+
+	if err != nil {
+		job.ReportError(err)
+		return nil, err
+	}
+
+	result.patch = string(patch)
+
+	result.flow, err = job.GetOrCreateFlow(result.flowTag, result.variant, c["template"])
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
 func (p *SQLPlugin) performAllTasks(j *job.Job) {
 	j.Log("Starting SQL plugin...")
 
@@ -126,25 +255,65 @@ func (p *SQLPlugin) performAllTasks(j *job.Job) {
 		return
 	}
 
-	rs, err := db.Query(p.query)
+	defer db.Close()
+
+	var tx *sql.Tx
+
+	// The plugin never writes to the database; the transaction only exists so that
+	// all the queries see the same snapshot of the data.
+
+	if options, ok := sqlTransactionOptions[p.driverName]; ok {
+		tx, err = db.BeginTx(context.Background(), options)
+	} else {
+		tx, err = db.Begin()
+	}
 
 	if err != nil {
 		j.ReportError(err)
 		return
 	}
 
+	defer tx.Rollback()
+
+	for _, q := range p.queries {
+		if err := q.perform(j, tx); err != nil {
+			j.ReportError(err)
+			return
+		}
+	}
+
+	for _, q := range p.queries {
+		j.Logf("Posting flow %s (%s)", q.flowTag, q.flow.Id)
+
+		j.PostFlowUpdate(q.flow)
+	}
+}
+
+// perform runs the query inside the given transaction and applies the result to
+// the query's flow. The flow is not posted.
+func (q *sqlQuery) perform(j *job.Job, tx *sql.Tx) error {
+	rs, err := tx.Query(q.query)
+
+	if err != nil {
+		return err
+	}
+
 	defer rs.Close()
 
-	if err := j.ReadFlow(p.flow); err != nil {
-		j.ReportError(err)
-		return
+	if err := j.ReadFlow(q.flow); err != nil {
+		return err
 	}
 
-	doc, err := json.Marshal(p.flow.Data)
+	doc, err := json.Marshal(q.flow.Data)
 
 	if err != nil {
-		j.ReportError(err)
-		return
+		return err
+	}
+
+	columns, err := rs.Columns()
+
+	if err != nil {
+		return err
 	}
 
 	rowIndex := 0
@@ -152,13 +321,6 @@ func (p *SQLPlugin) performAllTasks(j *job.Job) {
 	for rs.Next() {
 		row := []interface{}{}
 
-		columns, err := rs.Columns()
-
-		if err != nil {
-			j.ReportError(err)
-			return
-		}
-
 		for index := 0; index < len(columns); index++ {
 			var s interface{}
 			row = append(row, &s)
@@ -167,19 +329,16 @@ func (p *SQLPlugin) performAllTasks(j *job.Job) {
 		err = rs.Scan(row...)
 
 		if err != nil {
-			j.ReportError(err)
-			return
+			return err
 		}
 
-		patchSource := strings.Replace(p.patch, "$$row", strconv.Itoa(rowIndex), -1)
+		patchSource := strings.Replace(q.patch, "$$row", strconv.Itoa(rowIndex), -1)
 
 		rowIndex += 1
 
 		for index, col := range row {
 			c := *(col.(*interface{}))
 
-			j.Logf("%#T", c)
-
 			switch c.(type) {
 			case []uint8:
 				col = string(c.([]uint8))
@@ -188,35 +347,28 @@ func (p *SQLPlugin) performAllTasks(j *job.Job) {
 			v, err := json.Marshal(col)
 
 			if err != nil {
-				j.ReportError(err)
-				return
+				return err
 			}
 
 			patchSource = strings.Replace(patchSource, fmt.Sprintf(`"$$%d"`, index), string(v), -1)
-
-			if err != nil {
-				j.ReportError(err)
-				return
-			}
 		}
 
 		patch, err := jsonpatch.DecodePatch([]byte(patchSource))
 
+		if err != nil {
+			return err
+		}
+
 		doc, err = patch.Apply(doc)
 
 		if err != nil {
-			j.ReportError(err)
-			return
+			return err
 		}
 	}
 
-	err = json.Unmarshal(doc, &p.flow.Data)
-
-	if err != nil {
-		j.ReportError(err)
+	if err := rs.Err(); err != nil {
+		return err
 	}
 
-	j.Logf("Posting flow %s (%s)", p.flowTag, p.flow.Id)
-
-	j.PostFlowUpdate(p.flow)
+	return json.Unmarshal(doc, &q.flow.Data)
 }